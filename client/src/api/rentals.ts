@@ -1,9 +1,16 @@
 import axios from "./axios"
 
-export async function getRentals(){
+// Follows the page cursors until every matching rental is loaded
+export async function getRentals(params = {}){
 try {
-    const response = await axios.get('http://localhost:3001/api/rental/list')
-    return response.data
+    const rentals = []
+    let cursor = undefined
+    do {
+      const response = await axios.get('http://localhost:3001/api/rental/list', { params: { ...params, limit: 100, cursor } })
+      rentals.push(...response.data.items)
+      cursor = response.data.nextCursor
+    } while (cursor)
+    return rentals
   } catch (error) {
     console.error("Failed to fetch rentals:", error)
   }
@@ -18,10 +25,10 @@ try {
   }
 }
 
-export async function getRentalsByUserId(id:string){
+export async function getRentalsByUserId(id:string, params = {}){
 try {
-    const response = await axios.get(`http://localhost:3001/api/rental/user/${id}`)
-    return response.data
+    const response = await axios.get(`http://localhost:3001/api/rental/user/${id}`, { params })
+    return response.data.items
   } catch (error) {
     console.error("Failed to fetch rental with id:",id, error)
   }
@@ -34,8 +41,29 @@ export async function addRental(data){
 return response
 }
 
-export async function updateRental(id, data){
-  const response = await axios.put(`http://localhost:3001/api/rental/${id}`, data);
+export async function uploadRentalImages(data){
+  const response = await axios.post("http://localhost:3001/api/rental/images", data, {
+    headers: { "Content-Type": "multipart/form-data" },
+  });
+  return response.data.images
+}
+
+export async function createRental(rental){
+  const response = await axios.post("http://localhost:3001/api/rental", rental);
+  return response
+}
+
+export async function updateRental(id, data, version){
+  const response = await axios.put(`http://localhost:3001/api/rental/${id}`, data, {
+    headers: { "If-Match": `"${version}"` },
+  });
+  return response
+}
+
+export async function patchRental(id, patch, version){
+  const response = await axios.patch(`http://localhost:3001/api/rental/${id}`, patch, {
+    headers: { "Content-Type": "application/merge-patch+json", "If-Match": `"${version}"` },
+  });
   return response
 }
 
@@ -43,3 +71,83 @@ export async function deleteRental(id){
   const response = await axios.delete(`http://localhost:3001/api/rental/${id}`);
   return response
 }
+
+export async function saveDraft(rental){
+  const response = await axios.post("http://localhost:3001/api/rental/drafts", rental);
+  return response
+}
+
+export async function updateDraft(id, rental, version){
+  const response = await axios.put(`http://localhost:3001/api/rental/drafts/${id}`, rental, {
+    headers: { "If-Match": `"${version}"` },
+  });
+  return response
+}
+
+export async function getDrafts(params = {}){
+  const response = await axios.get("http://localhost:3001/api/rental/drafts", { params });
+  return response.data.items
+}
+
+export async function publishRental(id, publishAt?: string){
+  const response = await axios.post(`http://localhost:3001/api/rental/${id}/publish`, publishAt ? { publishAt } : {});
+  return response
+}
+
+export async function getExpiringRentals(params = {}){
+  const response = await axios.get("http://localhost:3001/api/rental/expiring", { params });
+  return response.data.items
+}
+
+export async function renewRental(id){
+  const response = await axios.post(`http://localhost:3001/api/rental/${id}/renew`);
+  return response.data
+}
+
+export async function importRentals(file: File, dryRun = false){
+  const data = new FormData();
+  data.append("file", file);
+  const response = await axios.post("http://localhost:3001/api/rental/import", data, {
+    headers: { "Content-Type": "multipart/form-data" },
+    params: { dryRun },
+  });
+  return response.data
+}
+
+export async function exportRentals(format: "csv" | "geojson" | "kml", params = {}){
+  const response = await axios.get("http://localhost:3001/api/rental/export", {
+    params: { ...params, format },
+    responseType: "blob",
+  });
+  return response.data
+}
+
+export async function getSuspectedDuplicates(params = {}){
+  const response = await axios.get("http://localhost:3001/api/admin/rentals/duplicates", { params });
+  return response.data
+}
+
+export async function dismissDuplicate(id, otherId){
+  const response = await axios.delete(`http://localhost:3001/api/rental/${id}/duplicates/${otherId}`);
+  return response.data
+}
+
+export async function getFavorites(params = {}){
+  const response = await axios.get("http://localhost:3001/api/favorites", { params });
+  return response.data.items
+}
+
+export async function addFavorite(rentalId){
+  const response = await axios.put(`http://localhost:3001/api/favorites/${rentalId}`);
+  return response.data
+}
+
+export async function removeFavorite(rentalId){
+  const response = await axios.delete(`http://localhost:3001/api/favorites/${rentalId}`);
+  return response.data
+}
+
+export async function getFavoriteCounts(){
+  const response = await axios.get("http://localhost:3001/api/favorites/counts");
+  return response.data
+}
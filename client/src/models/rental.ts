@@ -5,10 +5,15 @@ export interface Rental {
   geometry: Geometry; // Latitude and Longitude
   images: string[]; // URLs of rental images
   agreeToTerms: boolean; // Agreement to terms
-  status: "agreed" | "declined" | "pending"; // Rental status
+  status: "agreed" | "declined" | "pending" | "draft" | "expired"; // Rental status, drafts are only visible to their owner
+  publishAt?: string; // ISO string for the scheduled publication of a draft
+  expiresAt?: string; // ISO string for the end of the listing lifetime, renewable by the owner
+  duplicates?: DuplicateLink[]; // Rentals suspected to be the same flat, dismissed by admins
   description: string; // Rental description
   price: number; // Rental price
   currency: "TND" | "USD" | "EUR"; // Currency
+  priceTND?: number; // Price converted to TND, unset while the currency has no exchange rate
+  displayPrice?: DisplayPrice; // Price converted to the currency asked with ?currency=
   bedrooms: number; // Number of bedrooms
   bathrooms: number; // Number of bathrooms
   areaSize: number; // Size in square meters
@@ -16,6 +21,9 @@ export interface Rental {
   availableFrom: string; // ISO string for availability start date
   tags: string[]; // Associated tags
   type: "shared" | "independent" | "sale"; // Rental type
+  shared?: SharedDetails; // Set for shared rentals only
+  sale?: SaleDetails; // Set for sale listings only
+  independent?: IndependentDetails; // Set for independent rentals only
   standing: "economy" | "standard" | "luxury"; // Rental standing
   amenities: Amenities; // Rental amenities
   rules: Rules; // Rental rules
@@ -25,6 +33,41 @@ export interface Rental {
   updatedBy: string; // User ID of the last updater
   deletedAt?: string; // ISO string for deletion time (soft delete)
   lastUpdatedBy: string; // User ID of the last updater (audit logging)
+  version: number; // Incremented on every change, sent back in If-Match when updating
+}
+
+// Link to a rental suspected to be the same flat
+export interface DuplicateLink {
+  rentalId: string; // ID of the other rental
+  reasons: ("address" | "location" | "listing" | "images")[]; // Why the rentals look alike
+  detectedAt: string; // ISO string for the detection time
+}
+
+// Price converted to the currency the client displays prices in
+export interface DisplayPrice {
+  amount: number; // Converted price
+  currency: "TND" | "USD" | "EUR"; // Currency asked for
+  rate: number; // Value of one unit of the rental currency in the asked currency
+}
+
+// Details of a room in a shared home
+export interface SharedDetails {
+  roomCount: number; // Rooms of the home
+  occupants: number; // Current flatmates
+  genderPreference: "any" | "male" | "female"; // Preferred gender of the tenant
+}
+
+// Details of a property for sale
+export interface SaleDetails {
+  salePrice: number; // Asking price
+  titleStatus: "registered" | "in_progress" | "unregistered"; // Land title status
+  negotiable: boolean; // Whether the price is negotiable
+}
+
+// Details of the lease of a whole home
+export interface IndependentDetails {
+  leaseMonths: number; // Lease length in months
+  deposit: number; // Security deposit
 }
 
 // Nested Address structure
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
 	"server/config"
 	"server/internal/server"
 
@@ -9,16 +11,57 @@ import (
 )
 
 func main() {
-	// Initialize Echo framework
-	e := echo.New()
-
 	// Load configuration from the environment or .env file
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Run a maintenance command when one is given, e.g. `go run main.go purge -days 30`
+	// or `go run main.go rates -file rates.json`
+	// or `go run main.go import -file units.csv -owner <user ID> -dry-run`
+	if len(os.Args) > 1 {
+		runCommand(cfg, os.Args[1], os.Args[2:])
+		return
+	}
+
+	// Initialize Echo framework
+	e := echo.New()
+
 	// Initialize and set up the server
 	client := &server.Server{}
 	client.SetupAndLaunch(e, cfg)
 }
+
+func runCommand(cfg *config.Config, name string, args []string) {
+	switch name {
+	case "purge":
+		flags := flag.NewFlagSet("purge", flag.ExitOnError)
+		days := flags.Int("days", 30, "purge rentals soft deleted more than this many days ago")
+		flags.Parse(args)
+
+		if err := server.PurgeDeletedRentals(cfg, *days); err != nil {
+			log.Fatalf("Failed to purge deleted rentals: %v", err)
+		}
+	case "rates":
+		flags := flag.NewFlagSet("rates", flag.ExitOnError)
+		file := flags.String("file", "", "JSON or CSV file of exchange rates to TND, e.g. rates.json")
+		flags.Parse(args)
+
+		if err := server.LoadExchangeRates(cfg, *file); err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+	case "import":
+		flags := flag.NewFlagSet("import", flag.ExitOnError)
+		file := flags.String("file", "", "CSV or JSON Lines file of rentals, e.g. units.csv or units.jsonl")
+		owner := flags.String("owner", "", "ID of the user the rentals are listed for")
+		dryRun := flags.Bool("dry-run", false, "validate the rows without creating the rentals")
+		flags.Parse(args)
+
+		if err := server.ImportRentals(cfg, *file, *owner, *dryRun); err != nil {
+			log.Fatalf("Failed to import rentals: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown command %q, available commands: purge, rates, import", name)
+	}
+}
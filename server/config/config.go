@@ -18,6 +18,13 @@ type Config struct {
 	DatabasePassword   string
 	DatabasePort       int
 	DatabaseHost       string
+
+	// Days a published listing stays in search, per rental type
+	SharedLifetimeDays      int
+	IndependentLifetimeDays int
+	SaleLifetimeDays        int
+	// Days before expiry owners are warned
+	ExpiryWarningDays int
 }
 
 // LoadConfig reads the environment variables and populates the Config struct
@@ -45,6 +52,11 @@ func LoadConfig() (*Config, error) {
 		DatabasePassword:   GetEnv("DATABASE_PASSWORD", "secret"),
 		DatabasePort:       GetEnvAsInt("DATABASE_PORT", 27017),
 		DatabaseHost:       GetEnv("DATABASE_HOST", "localhost"),
+
+		SharedLifetimeDays:      GetEnvAsInt("SHARED_LIFETIME_DAYS", 30),
+		IndependentLifetimeDays: GetEnvAsInt("INDEPENDENT_LIFETIME_DAYS", 60),
+		SaleLifetimeDays:        GetEnvAsInt("SALE_LIFETIME_DAYS", 90),
+		ExpiryWarningDays:       GetEnvAsInt("EXPIRY_WARNING_DAYS", 7),
 	}
 
 	return config, nil
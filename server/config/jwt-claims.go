@@ -37,16 +37,20 @@ func GenerateToken(userID, email, role string) (string, error) {
 	return token.SignedString(SecretKey)
 }
 
-// ParseToken validates a JWT token and returns the claims if valid
-func ParseToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+// ParseJWT validates a JWT token and returns the parsed token, its claims being *JWTClaims
+func ParseJWT(tokenString string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Ensure the signing method is HMAC
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
 		return SecretKey, nil
 	})
+}
 
+// ParseToken validates a JWT token and returns the claims if valid
+func ParseToken(tokenString string) (*JWTClaims, error) {
+	token, err := ParseJWT(tokenString)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,6 @@
 module server
 
-go 1.20
+go 1.21
 
 require (
 	github.com/brianvoe/gofakeit/v6 v6.28.0
@@ -12,6 +12,7 @@ require (
 	go.mongodb.org/mongo-driver v1.17.1
 	golang.org/x/crypto v0.26.0
 	golang.org/x/oauth2 v0.24.0
+	golang.org/x/text v0.17.0
 )
 
 require (
@@ -36,5 +37,4 @@ require (
 	golang.org/x/net v0.24.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.23.0 // indirect
-	golang.org/x/text v0.17.0 // indirect
 )
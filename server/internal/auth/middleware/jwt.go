@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"server/config"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// RequireAuth rejects requests without a valid auth token.
+// The token is read from the auth_token cookie or from an "Authorization: Bearer" header,
+// and stored in the context under "user" as a *jwt.Token.
+func RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, err := config.ParseJWT(tokenFromRequest(c))
+		if err != nil || !token.Valid {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Authentication required"})
+		}
+
+		c.Set("user", token)
+		return next(c)
+	}
+}
+
+// OptionalAuth identifies the user of the requests that carry a valid auth token, like RequireAuth,
+// but lets the anonymous requests through. Claims tells them apart.
+func OptionalAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if token, err := config.ParseJWT(tokenFromRequest(c)); err == nil && token.Valid {
+			c.Set("user", token)
+		}
+		return next(c)
+	}
+}
+
+// RequireAdmin rejects requests that are not made by an authenticated admin
+func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return RequireAuth(func(c echo.Context) error {
+		if claims := Claims(c); claims == nil || claims.Role != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+		}
+		return next(c)
+	})
+}
+
+// Claims returns the claims of the authenticated user, or nil when the request is anonymous
+func Claims(c echo.Context) *config.JWTClaims {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return nil
+	}
+	claims, _ := token.Claims.(*config.JWTClaims)
+	return claims
+}
+
+func tokenFromRequest(c echo.Context) string {
+	if cookie, err := c.Cookie("auth_token"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	return strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+}
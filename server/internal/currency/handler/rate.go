@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"server/config"
+	"server/internal/currency/service"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CurrencyHandler struct {
+	service service.CurrencyService
+}
+
+func NewCurrencyHandler(service service.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{service: service}
+}
+
+// GetRates handles the GET request to retrieve the exchange rate table
+func (h *CurrencyHandler) GetRates(c echo.Context) error {
+	table, err := h.service.GetRates(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve exchange rates"})
+	}
+
+	return c.JSON(http.StatusOK, table)
+}
+
+// SetRates handles the PUT request of an admin updating exchange rates: {"rates": {"USD": 3.1, "EUR": 3.4}}.
+// Currencies left out keep their current rate.
+func (h *CurrencyHandler) SetRates(c echo.Context) error {
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+
+	actor, err := adminIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	table, err := h.service.SetRates(c.Request().Context(), body.Rates, actor)
+	if err != nil {
+		return rateError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, table)
+}
+
+// ImportRates handles the POST request of an admin loading exchange rates from a file (multipart field "rates").
+// The format is taken from the format field, or from the file extension: .json or .csv.
+func (h *CurrencyHandler) ImportRates(c echo.Context) error {
+	file, err := c.FormFile("rates")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "A rates file is required"})
+	}
+	format := c.FormValue("format")
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(file.Filename), ".")
+	}
+
+	actor, err := adminIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read rates file"})
+	}
+	defer src.Close()
+
+	table, err := h.service.ImportRates(c.Request().Context(), src, format, actor)
+	if err != nil {
+		return rateError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, table)
+}
+
+func adminIDFromClaims(c echo.Context) (primitive.ObjectID, error) {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+	id, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("invalid user ID")
+	}
+	return id, nil
+}
+
+func rateError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUnsupportedCurrency), errors.Is(err, service.ErrInvalidRates):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update exchange rates"})
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"server/internal/currency/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RateRepository interface {
+	GetRates(ctx context.Context) ([]types.Rate, error)
+	SetRates(ctx context.Context, rates []types.Rate) error
+}
+
+type rateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRateRepository(db *mongo.Database) RateRepository {
+	return &rateRepository{
+		collection: db.Collection("exchange_rates"),
+	}
+}
+
+// GetRates retrieves the stored exchange rates, ordered by currency
+func (r *rateRepository) GetRates(ctx context.Context) ([]types.Rate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding exchange rates: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rates := []types.Rate{}
+	if err = cursor.All(ctx, &rates); err != nil {
+		log.Printf("Error decoding exchange rates: %v", err)
+		return nil, err
+	}
+	return rates, nil
+}
+
+// SetRates stores the given rates, replacing the previous rate of each currency
+func (r *rateRepository) SetRates(ctx context.Context, rates []types.Rate) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	models := make([]mongo.WriteModel, 0, len(rates))
+	for _, rate := range rates {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": rate.Currency}).
+			SetReplacement(rate).
+			SetUpsert(true))
+	}
+	if _, err := r.collection.BulkWrite(ctx, models); err != nil {
+		log.Printf("Error storing exchange rates: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"server/internal/currency/repository"
+	"server/internal/currency/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// cacheTTL is how long the rate table is kept in memory before being read again,
+// so that rates changed through another server instance are picked up
+const cacheTTL = 5 * time.Minute
+
+var (
+	// ErrUnsupportedCurrency is returned for a currency outside of types.Supported
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrMissingRate is returned when a conversion needs a rate that was never set
+	ErrMissingRate = errors.New("no exchange rate set for this currency")
+	// ErrInvalidRates is returned when a rate table or a rate file is malformed
+	ErrInvalidRates = errors.New("invalid exchange rates")
+)
+
+// Repricer recomputes the normalised prices of the rentals after the rates changed
+type Repricer interface {
+	RepriceRentals(ctx context.Context, rates map[string]float64) (int64, error)
+}
+
+type CurrencyService interface {
+	GetRates(ctx context.Context) (*types.RateTable, error)
+	SetRates(ctx context.Context, rates map[string]float64, actor primitive.ObjectID) (*types.RateTable, error)
+	ImportRates(ctx context.Context, src io.Reader, format string, actor primitive.ObjectID) (*types.RateTable, error)
+	ToBase(ctx context.Context, amount int64, currency string) (int64, error)
+	Convert(ctx context.Context, amount int64, from, to string) (int64, float64, error)
+	RepriceRentals(ctx context.Context) (int64, error)
+}
+
+type currencyService struct {
+	repo     repository.RateRepository
+	repricer Repricer
+
+	mu       sync.RWMutex
+	cached   *types.RateTable
+	cachedAt time.Time
+}
+
+func NewCurrencyService(repo repository.RateRepository, repricer Repricer) CurrencyService {
+	return &currencyService{repo: repo, repricer: repricer}
+}
+
+// GetRates retrieves the exchange rate table
+func (s *currencyService) GetRates(ctx context.Context) (*types.RateTable, error) {
+	s.mu.RLock()
+	if s.cached != nil && time.Since(s.cachedAt) < cacheTTL {
+		defer s.mu.RUnlock()
+		return s.cached, nil
+	}
+	s.mu.RUnlock()
+
+	rates, err := s.repo.GetRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := &types.RateTable{Base: types.BaseCurrency, Rates: rates}
+
+	s.mu.Lock()
+	s.cached, s.cachedAt = table, time.Now()
+	s.mu.Unlock()
+	return table, nil
+}
+
+// SetRates updates the rates of the given currencies and reprices the rentals accordingly.
+// Rates are the value of one unit of each currency in the base currency, whose own rate is always 1.
+func (s *currencyService) SetRates(ctx context.Context, rates map[string]float64, actor primitive.ObjectID) (*types.RateTable, error) {
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: no rate given", ErrInvalidRates)
+	}
+
+	now := time.Now()
+	updates := make([]types.Rate, 0, len(rates))
+	for currency, rate := range rates {
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !types.IsSupported(currency) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+		}
+		if currency == types.BaseCurrency {
+			if rate != 1 {
+				return nil, fmt.Errorf("%w: the rate of %s is always 1", ErrInvalidRates, types.BaseCurrency)
+			}
+			continue
+		}
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return nil, fmt.Errorf("%w: the rate of %s must be a positive number", ErrInvalidRates, currency)
+		}
+		updates = append(updates, types.Rate{Currency: currency, Rate: rate, UpdatedAt: now, UpdatedBy: actor})
+	}
+
+	if len(updates) > 0 {
+		if err := s.repo.SetRates(ctx, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.cached = nil
+	s.mu.Unlock()
+
+	table, err := s.GetRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failures are only logged, the rates are stored and the prices follow on the next update
+	if count, err := s.RepriceRentals(ctx); err != nil {
+		log.Printf("Failed to reprice rentals: %v", err)
+	} else {
+		log.Printf("Repriced %d rentals after an exchange rate update", count)
+	}
+	return table, nil
+}
+
+// ImportRates loads rates from a file, either JSON or CSV:
+// {"USD": 3.1, "EUR": 3.4}, optionally wrapped as {"rates": {...}}, or lines of currency,rate with an optional header.
+func (s *currencyService) ImportRates(ctx context.Context, src io.Reader, format string, actor primitive.ObjectID) (*types.RateTable, error) {
+	var rates map[string]float64
+	var err error
+	switch strings.ToLower(format) {
+	case "json":
+		rates, err = parseJSONRates(src)
+	case "csv":
+		rates, err = parseCSVRates(src)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q, use json or csv", ErrInvalidRates, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.SetRates(ctx, rates, actor)
+}
+
+// ToBase converts an amount to the base currency
+func (s *currencyService) ToBase(ctx context.Context, amount int64, currency string) (int64, error) {
+	converted, _, err := s.Convert(ctx, amount, currency, types.BaseCurrency)
+	return converted, err
+}
+
+// Convert converts an amount between two currencies through the base currency, rounded to the unit.
+// It also returns the rate applied, the value of one unit of from in to.
+func (s *currencyService) Convert(ctx context.Context, amount int64, from, to string) (int64, float64, error) {
+	if from == "" {
+		from = types.BaseCurrency
+	}
+	if !types.IsSupported(from) {
+		return 0, 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, from)
+	}
+	if !types.IsSupported(to) {
+		return 0, 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+	if from == to {
+		return amount, 1, nil
+	}
+
+	table, err := s.GetRates(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	fromRate, ok := table.Lookup(from)
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %s", ErrMissingRate, from)
+	}
+	toRate, ok := table.Lookup(to)
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %s", ErrMissingRate, to)
+	}
+
+	rate := fromRate / toRate
+	return int64(math.Round(float64(amount) * rate)), rate, nil
+}
+
+// RepriceRentals recomputes the normalised prices of the rentals from the current rates.
+// It returns the number of rentals whose normalised price changed.
+func (s *currencyService) RepriceRentals(ctx context.Context) (int64, error) {
+	if s.repricer == nil {
+		return 0, nil
+	}
+	table, err := s.GetRates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rates := map[string]float64{types.BaseCurrency: 1}
+	for _, rate := range table.Rates {
+		rates[rate.Currency] = rate.Rate
+	}
+	return s.repricer.RepriceRentals(ctx, rates)
+}
+
+func parseJSONRates(src io.Reader) (map[string]float64, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(src).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRates, err)
+	}
+	if wrapped, ok := raw["rates"]; ok {
+		var rates map[string]float64
+		if err := json.Unmarshal(wrapped, &rates); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRates, err)
+		}
+		return rates, nil
+	}
+
+	rates := make(map[string]float64, len(raw))
+	for currency, value := range raw {
+		var rate float64
+		if err := json.Unmarshal(value, &rate); err != nil {
+			return nil, fmt.Errorf("%w: the rate of %s is not a number", ErrInvalidRates, currency)
+		}
+		rates[currency] = rate
+	}
+	return rates, nil
+}
+
+func parseCSVRates(src io.Reader) (map[string]float64, error) {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRates, err)
+	}
+
+	rates := make(map[string]float64, len(records))
+	for i, record := range records {
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			if i == 0 {
+				// Header line
+				continue
+			}
+			return nil, fmt.Errorf("%w: line %d: the rate of %s is not a number", ErrInvalidRates, i+1, record[0])
+		}
+		rates[record[0]] = rate
+	}
+	return rates, nil
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BaseCurrency is the currency rates are expressed in and prices are normalised to for searching
+const BaseCurrency = "TND"
+
+// Supported lists the currencies rentals can be priced in
+var Supported = []string{"TND", "USD", "EUR"}
+
+// IsSupported reports whether a currency code is one of the supported currencies
+func IsSupported(currency string) bool {
+	for _, supported := range Supported {
+		if supported == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// Rate is the exchange rate of a currency against the base currency
+type Rate struct {
+	Currency  string             `json:"currency" bson:"_id"`
+	Rate      float64            `json:"rate" bson:"rate"` // Value of one unit of the currency in the base currency
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+	UpdatedBy primitive.ObjectID `json:"updatedBy,omitempty" bson:"updatedBy,omitempty"` // Unset when loaded from the command line
+}
+
+// RateTable is the exchange rate table, one rate per supported currency
+type RateTable struct {
+	Base  string `json:"base"`
+	Rates []Rate `json:"rates"`
+}
+
+// Lookup returns the rate of a currency, the base currency always being worth 1
+func (t RateTable) Lookup(currency string) (float64, bool) {
+	if currency == BaseCurrency {
+		return 1, true
+	}
+	for _, rate := range t.Rates {
+		if rate.Currency == currency {
+			return rate.Rate, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"server/config"
+	"server/internal/favorite/service"
+	rentalUtils "server/internal/rental/utils"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type FavoriteHandler struct {
+	service service.FavoriteService
+}
+
+func NewFavoriteHandler(service service.FavoriteService) *FavoriteHandler {
+	return &FavoriteHandler{service: service}
+}
+
+// AddFavorite handles the PUT request to bookmark a rental for the authenticated user
+func (h *FavoriteHandler) AddFavorite(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	favorite, err := h.service.AddFavorite(c.Request().Context(), claims.UserID, c.Param("rentalId"))
+	if err != nil {
+		if errors.Is(err, service.ErrRentalNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, favorite)
+}
+
+// RemoveFavorite handles the DELETE request to remove a rental from the favorites of the authenticated user
+func (h *FavoriteHandler) RemoveFavorite(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	if err := h.service.RemoveFavorite(c.Request().Context(), claims.UserID, c.Param("rentalId")); err != nil {
+		if errors.Is(err, service.ErrFavoriteNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Favorite removed successfully"})
+}
+
+// GetFavorites handles the GET request to list the rentals bookmarked by the authenticated user, newest favorites first.
+// It accepts the limit, cursor and total pagination parameters of the rental lists.
+func (h *FavoriteHandler) GetFavorites(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	pageRequest, err := rentalUtils.ParsePageRequest(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := h.service.GetFavorites(c.Request().Context(), claims.UserID, pageRequest)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve favorites"})
+	}
+
+	// Convert image file paths to public URLs
+	for i := range page.Items {
+		page.Items[i].Rental.Images = rentalUtils.MapImagePathsToURLs(c, page.Items[i].Rental.Images)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetFavoriteCounts handles the GET request of an owner for the number of favorites of each of their rentals
+func (h *FavoriteHandler) GetFavoriteCounts(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	counts, err := h.service.GetFavoriteCounts(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to count favorites"})
+	}
+
+	return c.JSON(http.StatusOK, counts)
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"time"
+
+	"server/internal/favorite/types"
+	rentalRepository "server/internal/rental/repository"
+	rentalTypes "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrFavoriteNotFound is returned when the user has not bookmarked the rental
+	ErrFavoriteNotFound = errors.New("rental is not in the favorites")
+	// ErrInvalidCursor is returned when a page cursor cannot be decoded
+	ErrInvalidCursor = rentalRepository.ErrInvalidCursor
+)
+
+// favoriteCursor is the decoded form of FavoritePage.NextCursor, the date and ID of the last favorite of the page
+type favoriteCursor struct {
+	CreatedAt time.Time          `bson:"at"`
+	ID        primitive.ObjectID `bson:"id"`
+}
+
+// favoriteRental is a favorite joined with its rental by publishedRental
+type favoriteRental struct {
+	types.Favorite `bson:",inline"`
+	Rental         rentalTypes.Rental `bson:"rental"`
+}
+
+type FavoriteRepository interface {
+	AddFavorite(ctx context.Context, favorite *types.Favorite) error
+	RemoveFavorite(ctx context.Context, userID, rentalID primitive.ObjectID) error
+	GetFavoritesByUserID(ctx context.Context, userID primitive.ObjectID, page rentalTypes.PageRequest) (*types.FavoritePage, error)
+	CountByOwnerID(ctx context.Context, ownerID primitive.ObjectID) ([]types.FavoriteCount, error)
+	DeleteOrphans(ctx context.Context) (int64, error)
+}
+
+type favoriteRepository struct {
+	favorites *mongo.Collection
+}
+
+func NewFavoriteRepository(db *mongo.Database) FavoriteRepository {
+	return &favoriteRepository{
+		favorites: db.Collection("favorites"),
+	}
+}
+
+// AddFavorite bookmarks a rental for a user. Adding the same rental twice keeps the first favorite.
+func (r *favoriteRepository) AddFavorite(ctx context.Context, favorite *types.Favorite) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": favorite.UserID, "rentalId": favorite.RentalID}
+	update := bson.M{"$setOnInsert": bson.M{
+		"ownerId":   favorite.OwnerID,
+		"createdAt": time.Now(),
+	}}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := r.favorites.FindOneAndUpdate(ctx, filter, update, opts).Decode(favorite); err != nil {
+		log.Printf("Error adding favorite: %v", err)
+		return err
+	}
+	return nil
+}
+
+// RemoveFavorite removes a rental from the favorites of a user
+func (r *favoriteRepository) RemoveFavorite(ctx context.Context, userID, rentalID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.favorites.DeleteOne(ctx, bson.M{"userId": userID, "rentalId": rentalID})
+	if err != nil {
+		log.Printf("Error removing favorite: %v", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrFavoriteNotFound
+	}
+	return nil
+}
+
+// GetFavoritesByUserID retrieves one page of the favorites of a user along with their rentals, newest favorites first.
+// Favorites of rentals that have been deleted or are no longer published are left out,
+// but kept so that they come back if the rental is restored or published again.
+func (r *favoriteRepository) GetFavoritesByUserID(ctx context.Context, userID primitive.ObjectID, page rentalTypes.PageRequest) (*types.FavoritePage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := &types.FavoritePage{Items: []types.FavoriteRental{}}
+	if page.IncludeTotal {
+		total, err := r.countPublished(ctx, bson.M{"userId": userID})
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	filter := bson.M{"userId": userID}
+	if page.Cursor != "" {
+		cursor, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"createdAt": bson.M{"$lt": cursor.CreatedAt}},
+			bson.M{"createdAt": cursor.CreatedAt, "_id": bson.M{"$lt": cursor.ID}},
+		}
+	}
+
+	// Fetch one extra favorite to know whether another page exists
+	pipeline := append(mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}}},
+	}, publishedRental()...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$unwind", Value: "$rental"}},
+		bson.D{{Key: "$limit", Value: page.Limit + 1}},
+	)
+	cursor, err := r.favorites.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Error finding favorites: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var favorites []favoriteRental
+	if err = cursor.All(ctx, &favorites); err != nil {
+		log.Printf("Error decoding favorites: %v", err)
+		return nil, err
+	}
+
+	if int64(len(favorites)) > page.Limit {
+		favorites = favorites[:page.Limit]
+		last := favorites[len(favorites)-1]
+		next, err := encodeCursor(favoriteCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = next
+	}
+	for _, favorite := range favorites {
+		result.Items = append(result.Items, types.FavoriteRental{FavoritedAt: favorite.CreatedAt, Rental: favorite.Rental})
+	}
+	return result, nil
+}
+
+// countPublished counts the favorites matching filter whose rental is published
+func (r *favoriteRepository) countPublished(ctx context.Context, filter bson.M) (int64, error) {
+	pipeline := append(mongo.Pipeline{{{Key: "$match", Value: filter}}}, publishedRental()...)
+	pipeline = append(pipeline, bson.D{{Key: "$count", Value: "total"}})
+	cursor, err := r.favorites.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Error counting favorites: %v", err)
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var counts []struct {
+		Total int64 `bson:"total"`
+	}
+	if err = cursor.All(ctx, &counts); err != nil {
+		log.Printf("Error decoding favorite count: %v", err)
+		return 0, err
+	}
+	if len(counts) == 0 {
+		return 0, nil
+	}
+	return counts[0].Total, nil
+}
+
+// publishedRental joins favorites with their rental under "rental", as a one item array,
+// and drops those whose rental has been deleted or is not published
+func publishedRental() mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from": "rentals",
+			"let":  bson.M{"rentalId": "$rentalId"},
+			"pipeline": bson.A{bson.M{"$match": bson.M{
+				"$expr":     bson.M{"$eq": bson.A{"$_id", "$$rentalId"}},
+				"status":    rentalTypes.Agreed,
+				"deletedAt": nil,
+			}}},
+			"as": "rental",
+		}}},
+		{{Key: "$match", Value: bson.M{"rental": bson.M{"$ne": bson.A{}}}}},
+	}
+}
+
+func encodeCursor(cursor favoriteCursor) (string, error) {
+	raw, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(encoded string) (*favoriteCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor favoriteCursor
+	if err := bson.Unmarshal(raw, &cursor); err != nil || cursor.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// CountByOwnerID counts the favorites of each published rental of an owner.
+// Rentals nobody bookmarked are left out, as are deleted and unpublished rentals, whose favorites are not listed either.
+func (r *favoriteRepository) CountByOwnerID(ctx context.Context, ownerID primitive.ObjectID) ([]types.FavoriteCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Count first, the rentals are then looked up once each
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"ownerId": ownerID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$rentalId", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$addFields", Value: bson.M{"rentalId": "$_id"}}},
+	}
+	pipeline = append(pipeline, publishedRental()...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$project", Value: bson.M{"count": 1}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	)
+	cursor, err := r.favorites.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Error counting favorites: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := []types.FavoriteCount{}
+	if err = cursor.All(ctx, &counts); err != nil {
+		log.Printf("Error decoding favorite counts: %v", err)
+		return nil, err
+	}
+	return counts, nil
+}
+
+// DeleteOrphans deletes the favorites of rentals that no longer exist, i.e. that have been purged.
+// Favorites of soft deleted rentals are kept in case the rental is restored.
+func (r *favoriteRepository) DeleteOrphans(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{"from": "rentals", "localField": "rentalId", "foreignField": "_id", "as": "rental"}}},
+		{{Key: "$match", Value: bson.M{"rental": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := r.favorites.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Error finding orphan favorites: %v", err)
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var orphans []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &orphans); err != nil {
+		log.Printf("Error decoding orphan favorites: %v", err)
+		return 0, err
+	}
+	if len(orphans) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(orphans))
+	for i, orphan := range orphans {
+		ids[i] = orphan.ID
+	}
+	result, err := r.favorites.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		log.Printf("Error deleting orphan favorites: %v", err)
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"server/internal/favorite/repository"
+	"server/internal/favorite/types"
+	rentalRepository "server/internal/rental/repository"
+	rentalTypes "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrRentalNotFound is returned when bookmarking a rental that does not exist or is not published
+	ErrRentalNotFound = errors.New("no published rental found with the given ID")
+	// ErrFavoriteNotFound is returned when removing a rental that is not in the favorites
+	ErrFavoriteNotFound = repository.ErrFavoriteNotFound
+	// ErrInvalidCursor is returned when a page cursor cannot be decoded
+	ErrInvalidCursor = repository.ErrInvalidCursor
+)
+
+type FavoriteService interface {
+	AddFavorite(ctx context.Context, userID, rentalID string) (*types.Favorite, error)
+	RemoveFavorite(ctx context.Context, userID, rentalID string) error
+	GetFavorites(ctx context.Context, userID string, page rentalTypes.PageRequest) (*types.FavoritePage, error)
+	GetFavoriteCounts(ctx context.Context, ownerID string) ([]types.FavoriteCount, error)
+	DeleteOrphans(ctx context.Context) (int64, error)
+}
+
+type favoriteService struct {
+	repo       repository.FavoriteRepository
+	rentalRepo rentalRepository.RentalRepository
+}
+
+func NewFavoriteService(repo repository.FavoriteRepository, rentalRepo rentalRepository.RentalRepository) FavoriteService {
+	return &favoriteService{repo: repo, rentalRepo: rentalRepo}
+}
+
+// AddFavorite bookmarks a published rental for the user
+func (s *favoriteService) AddFavorite(ctx context.Context, userID, rentalID string) (*types.Favorite, error) {
+	ownerID, id, err := parseIDs(userID, rentalID)
+	if err != nil {
+		return nil, err
+	}
+
+	rental, err := s.rentalRepo.GetRentalByID(ctx, id.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if rental == nil || rental.Status != rentalTypes.Agreed {
+		return nil, ErrRentalNotFound
+	}
+
+	favorite := &types.Favorite{UserID: ownerID, RentalID: rental.ID, OwnerID: rental.CreatedBy}
+	if err := s.repo.AddFavorite(ctx, favorite); err != nil {
+		return nil, err
+	}
+	return favorite, nil
+}
+
+// RemoveFavorite removes a rental from the favorites of the user, whatever became of the rental
+func (s *favoriteService) RemoveFavorite(ctx context.Context, userID, rentalID string) error {
+	ownerID, id, err := parseIDs(userID, rentalID)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveFavorite(ctx, ownerID, id)
+}
+
+// GetFavorites lists one page of the rentals bookmarked by the user, newest favorites first.
+// Rentals that have been deleted or are no longer published are left out, their favorites are kept
+// so that they come back if the rental is restored or published again.
+func (s *favoriteService) GetFavorites(ctx context.Context, userID string, page rentalTypes.PageRequest) (*types.FavoritePage, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	// Favorites are always listed by the date they were added
+	page.Sort, page.Descending = rentalTypes.SortByCreatedAt, true
+	return s.repo.GetFavoritesByUserID(ctx, ownerID, page)
+}
+
+// GetFavoriteCounts counts how many users bookmarked each published rental of an owner, most bookmarked first
+func (s *favoriteService) GetFavoriteCounts(ctx context.Context, ownerID string) ([]types.FavoriteCount, error) {
+	id, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.repo.CountByOwnerID(ctx, id)
+}
+
+// DeleteOrphans deletes the favorites of purged rentals and returns how many were deleted
+func (s *favoriteService) DeleteOrphans(ctx context.Context) (int64, error) {
+	return s.repo.DeleteOrphans(ctx)
+}
+
+func parseIDs(userID, rentalID string) (primitive.ObjectID, primitive.ObjectID, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid user ID")
+	}
+	id, err := primitive.ObjectIDFromHex(rentalID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid rental ID")
+	}
+	return ownerID, id, nil
+}
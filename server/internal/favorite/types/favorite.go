@@ -0,0 +1,38 @@
+package types
+
+import (
+	"time"
+
+	rentalTypes "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Favorite is a rental bookmarked by a user.
+// The owner of the rental is copied so that owners can count the favorites of their listings.
+type Favorite struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"userId" bson:"userId"`
+	RentalID  primitive.ObjectID `json:"rentalId" bson:"rentalId"`
+	OwnerID   primitive.ObjectID `json:"ownerId" bson:"ownerId"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// FavoriteRental is a favorite along with the rental card it points to
+type FavoriteRental struct {
+	FavoritedAt time.Time          `json:"favoritedAt"`
+	Rental      rentalTypes.Rental `json:"rental"`
+}
+
+// FavoritePage is the response envelope of the paginated favorites, like rental pages
+type FavoritePage struct {
+	Items      []FavoriteRental `json:"items"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+	Total      *int64           `json:"total,omitempty"`
+}
+
+// FavoriteCount is the number of users who bookmarked a rental
+type FavoriteCount struct {
+	RentalID primitive.ObjectID `json:"rentalId" bson:"_id"`
+	Count    int64              `json:"count" bson:"count"`
+}
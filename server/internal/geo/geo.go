@@ -0,0 +1,127 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadius is the mean radius of the Earth in meters
+const EarthRadius = 6371e3
+
+// Point is a GeoJSON point. Coordinates are stored as [longitude, latitude].
+type Point struct {
+	Type        string     `json:"type" bson:"type"`
+	Coordinates [2]float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// NewPoint builds a GeoJSON point from a latitude and a longitude
+func NewPoint(lat, lng float64) Point {
+	return Point{Type: "Point", Coordinates: [2]float64{lng, lat}}
+}
+
+// ParsePoint builds a GeoJSON point from string coordinates
+func ParsePoint(lat, lng string) (Point, error) {
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return Point{}, errors.New("invalid latitude")
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lng), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return Point{}, errors.New("invalid longitude")
+	}
+	return NewPoint(latitude, longitude), nil
+}
+
+// Lat returns the latitude of the point
+func (p Point) Lat() float64 {
+	return p.Coordinates[1]
+}
+
+// Lng returns the longitude of the point
+func (p Point) Lng() float64 {
+	return p.Coordinates[0]
+}
+
+// Haversine calculates the distance in meters between two latitude/longitude pairs
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	deltaPhi := (lat2 - lat1) * math.Pi / 180
+	deltaLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return EarthRadius * c
+}
+
+// Distance returns the distance in meters between two points
+func Distance(a, b Point) float64 {
+	return Haversine(a.Lat(), a.Lng(), b.Lat(), b.Lng())
+}
+
+// Polygon is a GeoJSON polygon. The first ring is the outer boundary, the others are holes.
+type Polygon struct {
+	Type        string         `json:"type" bson:"type"`
+	Coordinates [][][2]float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// BoundingBox builds the polygon covering a longitude/latitude box
+func BoundingBox(minLng, minLat, maxLng, maxLat float64) (Polygon, error) {
+	if minLng >= maxLng || minLat >= maxLat {
+		return Polygon{}, errors.New("invalid bounding box: min must be lower than max")
+	}
+	if minLat < -90 || maxLat > 90 || minLng < -180 || maxLng > 180 {
+		return Polygon{}, errors.New("invalid bounding box: coordinates out of range")
+	}
+	return Polygon{
+		Type: "Polygon",
+		Coordinates: [][][2]float64{{
+			{minLng, minLat}, {maxLng, minLat}, {maxLng, maxLat}, {minLng, maxLat}, {minLng, minLat},
+		}},
+	}, nil
+}
+
+// ParseBoundingBox reads a "minLng,minLat,maxLng,maxLat" string
+func ParseBoundingBox(raw string) (Polygon, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return Polygon{}, errors.New("invalid bounding box: expected minLng,minLat,maxLng,maxLat")
+	}
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Polygon{}, errors.New("invalid bounding box: coordinates must be numbers")
+		}
+		values[i] = value
+	}
+	return BoundingBox(values[0], values[1], values[2], values[3])
+}
+
+// Validate checks that the polygon is a well formed GeoJSON polygon with closed rings
+func (p Polygon) Validate() error {
+	if p.Type != "Polygon" {
+		return errors.New("invalid polygon: type must be Polygon")
+	}
+	if len(p.Coordinates) == 0 {
+		return errors.New("invalid polygon: at least one ring is required")
+	}
+	for _, ring := range p.Coordinates {
+		if len(ring) < 4 {
+			return errors.New("invalid polygon: a ring needs at least 4 positions")
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return errors.New("invalid polygon: rings must be closed")
+		}
+		for _, position := range ring {
+			if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+				return errors.New("invalid polygon: coordinates out of range")
+			}
+		}
+	}
+	return nil
+}
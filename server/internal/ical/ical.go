@@ -0,0 +1,274 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405"
+	// maxLineLength is the length in octets after which content lines are folded (RFC 5545 section 3.1)
+	maxLineLength = 75
+)
+
+// ErrInvalidCalendar is returned when an iCalendar document cannot be parsed
+var ErrInvalidCalendar = errors.New("invalid calendar")
+
+// Calendar is an iCalendar (RFC 5545) document made of events
+type Calendar struct {
+	ProdID string
+	Name   string
+	Method string // Set for scheduling messages, e.g. REQUEST or CANCEL sent as email attachments
+	Events []Event
+}
+
+// Person is the organizer or an attendee of an event
+type Person struct {
+	Name  string
+	Email string
+}
+
+// Event is a VEVENT. All day events span whole dates, End is exclusive.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Status      string // TENTATIVE, CONFIRMED or CANCELLED
+	Sequence    int
+	Stamp       time.Time
+	Organizer   *Person
+	Attendees   []Person
+}
+
+// Encode writes the calendar as an iCalendar document
+func (c Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeLine(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	if c.Method != "" {
+		line("METHOD", c.Method)
+	}
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+
+	for _, event := range c.Events {
+		stamp := event.Stamp
+		if stamp.IsZero() {
+			stamp = time.Now()
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", stamp.UTC().Format(dateTimeFormat)+"Z")
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", event.Start.Format(dateFormat))
+			line("DTEND;VALUE=DATE", event.End.Format(dateFormat))
+		} else {
+			line("DTSTART", event.Start.UTC().Format(dateTimeFormat)+"Z")
+			line("DTEND", event.End.UTC().Format(dateTimeFormat)+"Z")
+		}
+		if event.Summary != "" {
+			line("SUMMARY", escapeText(event.Summary))
+		}
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escapeText(event.Location))
+		}
+		if event.Status != "" {
+			line("STATUS", event.Status)
+		}
+		if event.Sequence > 0 {
+			line("SEQUENCE", fmt.Sprint(event.Sequence))
+		}
+		if event.Organizer != nil {
+			writeLine(bw, "ORGANIZER"+personParams(*event.Organizer)+":mailto:"+event.Organizer.Email)
+		}
+		for _, attendee := range event.Attendees {
+			writeLine(bw, "ATTENDEE;ROLE=REQ-PARTICIPANT"+personParams(attendee)+":mailto:"+attendee.Email)
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// Parse reads the events of an iCalendar document.
+// Times with a TZID are read in that zone when it is known, floating times are read as UTC.
+// An event without an end lasts one day when it is all day, and no time otherwise.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	inCalendar := false
+	for _, raw := range lines {
+		name, params, value, ok := splitLine(raw)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			inCalendar = true
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				return nil, fmt.Errorf("%w: unexpected END:VEVENT", ErrInvalidCalendar)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("%w: event %q has no start", ErrInvalidCalendar, current.UID)
+			}
+			if current.End.IsZero() {
+				current.End = current.Start
+				if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			// Calendar properties and other components (VTIMEZONE, VTODO...) are not used
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescapeText(value)
+		case name == "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case name == "LOCATION":
+			current.Location = unescapeText(value)
+		case name == "STATUS":
+			current.Status = strings.ToUpper(value)
+		case name == "DTSTART", name == "DTEND":
+			t, allDay, err := parseTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %q", ErrInvalidCalendar, name, value)
+			}
+			if name == "DTSTART" {
+				current.Start, current.AllDay = t, allDay
+			} else {
+				current.End = t
+			}
+		}
+	}
+
+	if !inCalendar {
+		return nil, fmt.Errorf("%w: missing BEGIN:VCALENDAR", ErrInvalidCalendar)
+	}
+	return events, nil
+}
+
+// unfold joins the content lines continued on the next physical line
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitLine splits a content line into its upper-cased name, its parameters and its value
+func splitLine(line string) (string, map[string]string, string, bool) {
+	colon := strings.Index(line, ":")
+	if colon == -1 {
+		return "", nil, "", false
+	}
+	head, value := line[:colon], line[colon+1:]
+
+	parts := strings.Split(head, ";")
+	params := map[string]string{}
+	for _, param := range parts[1:] {
+		if eq := strings.Index(param, "="); eq != -1 {
+			params[strings.ToUpper(param[:eq])] = strings.Trim(param[eq+1:], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateFormat) {
+		t, err := time.Parse(dateFormat, value)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeFormat, strings.TrimSuffix(value, "Z"))
+		return t, false, err
+	}
+
+	location := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeFormat, value, location)
+	return t.UTC(), false, err
+}
+
+// writeLine writes a content line, folded so that no physical line exceeds maxLineLength octets
+func writeLine(w *bufio.Writer, line string) {
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > maxLineLength {
+			w.WriteString("\r\n ")
+			length = 1
+		}
+		w.WriteRune(r)
+		length += size
+	}
+	w.WriteString("\r\n")
+}
+
+// personParams returns the common name parameter of a person, quoted as it may hold separators
+func personParams(person Person) string {
+	if person.Name == "" {
+		return ""
+	}
+	return `;CN="` + strings.ReplaceAll(person.Name, `"`, "'") + `"`
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+func unescapeText(text string) string {
+	return textUnescaper.Replace(text)
+}
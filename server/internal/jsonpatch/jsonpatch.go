@@ -0,0 +1,353 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned when a patch document is malformed or points to a missing location
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed is returned when a "test" operation of a JSON Patch does not hold
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Operation is a single operation of a JSON Patch document (RFC 6902)
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// DecodePatch parses a JSON Patch document
+func DecodePatch(patch []byte) ([]Operation, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for _, op := range ops {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: %s %q requires a value", ErrInvalidPatch, op.Op, op.Path)
+			}
+		case "move", "copy":
+			if _, err := ParsePointer(op.From); err != nil {
+				return nil, err
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+		}
+		if _, err := ParsePointer(op.Path); err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
+
+// Apply applies the operations of a JSON Patch to a document, in order.
+// The document is left untouched when an operation fails.
+func Apply(doc []byte, ops []Operation) ([]byte, error) {
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		path, _ := ParsePointer(op.Path)
+		var err error
+
+		switch op.Op {
+		case "add", "replace", "test":
+			var value interface{}
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+			}
+			switch op.Op {
+			case "add":
+				root, err = add(root, path, value)
+			case "replace":
+				root, err = replace(root, path, value)
+			case "test":
+				var current interface{}
+				if current, err = get(root, path); err == nil && !reflect.DeepEqual(current, value) {
+					err = fmt.Errorf("%w: %q", ErrTestFailed, op.Path)
+				}
+			}
+		case "remove":
+			root, _, err = remove(root, path)
+		case "move", "copy":
+			from, _ := ParsePointer(op.From)
+			var value interface{}
+			if op.Op == "move" {
+				if isPrefix(from, path) && len(from) < len(path) {
+					return nil, fmt.Errorf("%w: cannot move %q into itself", ErrInvalidPatch, op.From)
+				}
+				root, value, err = remove(root, from)
+			} else {
+				value, err = get(root, from)
+				value = deepCopy(value)
+			}
+			if err == nil {
+				root, err = add(root, path, value)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+// MergePatch applies a JSON merge patch (RFC 7386) to a document.
+// Members of the patch set to null are removed, objects are merged recursively and any other value replaces the target.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+// MergePatchPaths lists the locations a merge patch sets or removes, as parsed JSON pointers.
+// Nested objects are walked down to the members they change.
+func MergePatchPaths(patch []byte) ([][]string, error) {
+	var changes interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	var paths [][]string
+	var walk func(prefix []string, value interface{})
+	walk = func(prefix []string, value interface{}) {
+		object, ok := value.(map[string]interface{})
+		if !ok || len(object) == 0 {
+			paths = append(paths, prefix)
+			return
+		}
+		for key, member := range object {
+			walk(append(append([]string{}, prefix...), key), member)
+		}
+	}
+	walk([]string{}, changes)
+	return paths, nil
+}
+
+// ParsePointer splits a JSON pointer (RFC 6901) into its unescaped reference tokens.
+// The empty pointer designates the whole document.
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func merge(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(object, key)
+		} else {
+			object[key] = merge(object[key], value)
+		}
+	}
+	return object
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			child, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPatch, token)
+			}
+			node = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = container[index]
+		default:
+			return nil, fmt.Errorf("%w: cannot descend into %q", ErrInvalidPatch, token)
+		}
+	}
+	return node, nil
+}
+
+// update runs change on the container holding the last token of path, and returns the new document
+func update(node interface{}, path []string, change func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return change(node, path[0])
+	}
+
+	token := path[0]
+	switch container := node.(type) {
+	case map[string]interface{}:
+		child, ok := container[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q not found", ErrInvalidPatch, token)
+		}
+		updated, err := update(child, path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[token] = updated
+		return container, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(container[index], path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = updated
+		return container, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot descend into %q", ErrInvalidPatch, token)
+	}
+}
+
+func add(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(root, path, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index := len(container)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(container)); err != nil {
+					return nil, err
+				}
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		default:
+			return nil, fmt.Errorf("%w: cannot add %q to a value", ErrInvalidPatch, token)
+		}
+	})
+}
+
+func replace(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if _, err := get(root, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(root, path, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index, _ := arrayIndex(token, len(container)-1)
+			container[index] = value
+			return container, nil
+		default:
+			return nil, fmt.Errorf("%w: cannot replace %q in a value", ErrInvalidPatch, token)
+		}
+	})
+}
+
+// remove deletes the value at path and returns the new document along with the removed value
+func remove(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	removed, err := get(root, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err = update(root, path, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, _ := arrayIndex(token, len(container)-1)
+			return append(container[:index], container[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: cannot remove %q from a value", ErrInvalidPatch, token)
+		}
+	})
+	return root, removed, err
+}
+
+// arrayIndex parses an array index token, which must fall between 0 and max
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("%w: array index %q out of range", ErrInvalidPatch, token)
+	}
+	return index, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			object[key] = deepCopy(member)
+		}
+		return object
+	case []interface{}:
+		array := make([]interface{}, len(v))
+		for i, item := range v {
+			array[i] = deepCopy(item)
+		}
+		return array
+	default:
+		return v
+	}
+}
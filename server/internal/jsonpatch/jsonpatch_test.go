@@ -0,0 +1,278 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "add member",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"/b","value":2}]`,
+			want:  `{"a":1,"b":2}`,
+		},
+		{
+			name:  "add replaces existing member",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"/a","value":[1]}]`,
+			want:  `{"a":[1]}`,
+		},
+		{
+			name:  "add inserts into array",
+			doc:   `{"a":[1,3]}`,
+			patch: `[{"op":"add","path":"/a/1","value":2}]`,
+			want:  `{"a":[1,2,3]}`,
+		},
+		{
+			name:  "add appends with dash",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"add","path":"/a/-","value":3}]`,
+			want:  `{"a":[1,2,3]}`,
+		},
+		{
+			name:  "add at array length",
+			doc:   `{"a":[1]}`,
+			patch: `[{"op":"add","path":"/a/1","value":2}]`,
+			want:  `{"a":[1,2]}`,
+		},
+		{
+			name:  "add past array length",
+			doc:   `{"a":[1]}`,
+			patch: `[{"op":"add","path":"/a/2","value":2}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "add to missing parent",
+			doc:   `{}`,
+			patch: `[{"op":"add","path":"/a/b","value":1}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "add whole document",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"","value":{"b":2}}]`,
+			want:  `{"b":2}`,
+		},
+		{
+			name:  "remove member",
+			doc:   `{"a":1,"b":2}`,
+			patch: `[{"op":"remove","path":"/a"}]`,
+			want:  `{"b":2}`,
+		},
+		{
+			name:  "remove array item",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"remove","path":"/a/1"}]`,
+			want:  `{"a":[1,3]}`,
+		},
+		{
+			name:  "remove missing member",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":"/b"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "remove dash is not an index",
+			doc:   `{"a":[1]}`,
+			patch: `[{"op":"remove","path":"/a/-"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "replace member",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"replace","path":"/a/b","value":"x"}]`,
+			want:  `{"a":{"b":"x"}}`,
+		},
+		{
+			name:  "replace array item",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"replace","path":"/a/0","value":9}]`,
+			want:  `{"a":[9,2]}`,
+		},
+		{
+			name:  "replace missing member",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"/b","value":2}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "move member",
+			doc:   `{"a":{"b":1},"c":{}}`,
+			patch: `[{"op":"move","from":"/a/b","path":"/c/d"}]`,
+			want:  `{"a":{},"c":{"d":1}}`,
+		},
+		{
+			name:  "move array item",
+			doc:   `{"a":[1,2,3]}`,
+			patch: `[{"op":"move","from":"/a/0","path":"/a/-"}]`,
+			want:  `{"a":[2,3,1]}`,
+		},
+		{
+			name:  "move into itself",
+			doc:   `{"a":{"b":{}}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "copy member",
+			doc:   `{"a":{"b":[1]}}`,
+			patch: `[{"op":"copy","from":"/a/b","path":"/c"},{"op":"add","path":"/c/-","value":2}]`,
+			want:  `{"a":{"b":[1]},"c":[1,2]}`,
+		},
+		{
+			name:  "copy missing member",
+			doc:   `{}`,
+			patch: `[{"op":"copy","from":"/a","path":"/b"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "test holds",
+			doc:   `{"a":{"b":[1,"x"]}}`,
+			patch: `[{"op":"test","path":"/a","value":{"b":[1,"x"]}},{"op":"add","path":"/c","value":true}]`,
+			want:  `{"a":{"b":[1,"x"]},"c":true}`,
+		},
+		{
+			name:  "test fails",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"test","path":"/a","value":2}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "test fails after earlier operations",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "escaped tilde and slash",
+			doc:   `{"a/b":1,"c~d":2}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/c~0d"}]`,
+			want:  `{"a/b":3}`,
+		},
+		{
+			name:  "escapes are unescaped in order",
+			doc:   `{"~1":1}`,
+			patch: `[{"op":"remove","path":"/~01"}]`,
+			want:  `{}`,
+		},
+		{
+			name:  "leading zero index",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"replace","path":"/a/01","value":3}]`,
+			err:   ErrInvalidPatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops, err := DecodePatch([]byte(test.patch))
+			if err != nil {
+				t.Fatalf("DecodePatch: %v", err)
+			}
+			got, err := Apply([]byte(test.doc), ops)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("Apply error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			assertJSON(t, got, test.want)
+		})
+	}
+}
+
+func TestDecodePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"not an array", `{"op":"add"}`},
+		{"unknown operation", `[{"op":"append","path":"/a","value":1}]`},
+		{"add without value", `[{"op":"add","path":"/a"}]`},
+		{"path without slash", `[{"op":"remove","path":"a"}]`},
+		{"move from without slash", `[{"op":"move","from":"a","path":"/b"}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := DecodePatch([]byte(test.patch)); !errors.Is(err, ErrInvalidPatch) {
+				t.Fatalf("DecodePatch error = %v, want %v", err, ErrInvalidPatch)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"set member", `{"a":1}`, `{"b":2}`, `{"a":1,"b":2}`},
+		{"null removes member", `{"a":1,"b":2}`, `{"a":null}`, `{"b":2}`},
+		{"objects merge", `{"a":{"b":1,"c":2}}`, `{"a":{"c":3}}`, `{"a":{"b":1,"c":3}}`},
+		{"arrays are replaced", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{"object replaces value", `{"a":1}`, `{"a":{"b":null,"c":1}}`, `{"a":{"c":1}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(test.doc), []byte(test.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+			assertJSON(t, got, test.want)
+		})
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+	}{
+		{"", []string{}},
+		{"/", []string{""}},
+		{"/a/0", []string{"a", "0"}},
+		{"/a~1b/c~0d", []string{"a/b", "c~d"}},
+		{"/~01", []string{"~1"}},
+	}
+
+	for _, test := range tests {
+		got, err := ParsePointer(test.pointer)
+		if err != nil {
+			t.Fatalf("ParsePointer(%q): %v", test.pointer, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParsePointer(%q) = %q, want %q", test.pointer, got, test.want)
+		}
+	}
+}
+
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid expectation %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"server/internal/geo"
 )
 
 type PlacesService struct {
@@ -87,21 +89,6 @@ func (service *PlacesService) GetPlaceDetails(placeID string) (map[string]interf
 	return placeDetails, nil
 }
 
-// haversine function to calculate distance between two latitude/longitude pairs
-func haversine(lat1, lon1, lat2, lon2 float64) float64 {
-	const R = 6371e3 // Earth's radius in meters
-	phi1 := lat1 * math.Pi / 180
-	phi2 := lat2 * math.Pi / 180
-	deltaPhi := (lat2 - lat1) * math.Pi / 180
-	deltaLambda := (lon2 - lon1) * math.Pi / 180
-
-	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
-		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return R * c
-}
-
 func (service *PlacesService) GetAddressFromLatLng(latitude, longitude string) (map[string]interface{}, error) {
 	if latitude == "" || longitude == "" {
 		return nil, errors.New("both latitude and longitude are required")
@@ -189,7 +176,7 @@ func (service *PlacesService) GetAddressFromLatLng(latitude, longitude string) (
 		// Calculate the distance to the provided coordinates
 		resultLat := result.Geometry.Location.Lat
 		resultLng := result.Geometry.Location.Lng
-		distance := haversine(lat, lon, resultLat, resultLng)
+		distance := geo.Haversine(lat, lon, resultLat, resultLng)
 
 		// Prioritize results with "route" type, but keep track of both types
 		if hasRouteType {
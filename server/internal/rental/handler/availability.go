@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"server/internal/rental/service"
+	types "server/internal/rental/types"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetRentalAvailability handles the GET request to retrieve the blocked and booked periods of a rental
+func (h *RentalHandler) GetRentalAvailability(c echo.Context) error {
+	rental, err := h.service.GetRentalByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch rental"})
+	}
+	if rental == nil || rental.Status != types.Agreed && !isOwnerOrAdmin(c, rental.CreatedBy.Hex()) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Rental not found"})
+	}
+
+	blocks := rental.Availability
+	if blocks == nil {
+		blocks = []types.AvailabilityBlock{}
+	}
+	return c.JSON(http.StatusOK, blocks)
+}
+
+// AddAvailabilityBlock handles the POST request of a landlord closing a period of the calendar.
+// The body holds the dates of the period, the end being exclusive: {"start": "2024-07-01", "end": "2024-07-15", "note": "..."}.
+func (h *RentalHandler) AddAvailabilityBlock(c echo.Context) error {
+	rental, ok, err := h.managedRental(c)
+	if !ok {
+		return err
+	}
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var body struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+		Note  string `json:"note"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+	start, err := time.Parse("2006-01-02", body.Start)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid start date, expected YYYY-MM-DD"})
+	}
+	end, err := time.Parse("2006-01-02", body.End)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid end date, expected YYYY-MM-DD"})
+	}
+
+	block, err := h.service.AddAvailabilityBlock(c.Request().Context(), rental.ID.Hex(), types.AvailabilityBlock{
+		Start: start,
+		End:   end,
+		Note:  body.Note,
+	}, actor)
+	if err != nil {
+		return availabilityError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, block)
+}
+
+// RemoveAvailabilityBlock handles the DELETE request of a landlord reopening a blocked period
+func (h *RentalHandler) RemoveAvailabilityBlock(c echo.Context) error {
+	rental, ok, err := h.managedRental(c)
+	if !ok {
+		return err
+	}
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	if err := h.service.RemoveAvailabilityBlock(c.Request().Context(), rental.ID.Hex(), c.Param("blockId"), actor); err != nil {
+		return availabilityError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Availability block removed successfully"})
+}
+
+// ExportRentalCalendar handles the GET request for the iCalendar feed of a rental,
+// which other listing sites can subscribe to
+func (h *RentalHandler) ExportRentalCalendar(c echo.Context) error {
+	rental, err := h.service.GetRentalByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch rental"})
+	}
+	if rental == nil || rental.Status != types.Agreed && !isOwnerOrAdmin(c, rental.CreatedBy.Hex()) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Rental not found"})
+	}
+
+	calendar := h.service.GetRentalCalendar(*rental)
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="rental-`+c.Param("id")+`.ics"`)
+	c.Response().WriteHeader(http.StatusOK)
+	return calendar.Encode(c.Response())
+}
+
+// ImportRentalCalendar handles the POST request of a landlord importing the calendar of another listing site.
+// The calendar is either an uploaded .ics file (multipart field "calendar") or a feed URL: {"url": "https://..."}.
+// Importing the same file name or URL again replaces the blocks it brought in, feed URLs are then kept in sync.
+func (h *RentalHandler) ImportRentalCalendar(c echo.Context) error {
+	rental, ok, err := h.managedRental(c)
+	if !ok {
+		return err
+	}
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var imported int
+	if file, err := c.FormFile("calendar"); err == nil {
+		src, err := file.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read calendar file"})
+		}
+		defer src.Close()
+
+		imported, err = h.service.ImportCalendar(c.Request().Context(), rental.ID.Hex(), "file:"+file.Filename, src, actor)
+		if err != nil {
+			return availabilityError(c, err)
+		}
+	} else {
+		var body struct {
+			URL string `json:"url"`
+		}
+		if err := c.Bind(&body); err != nil || body.URL == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "A calendar file or URL is required"})
+		}
+
+		imported, err = h.service.ImportCalendarURL(c.Request().Context(), rental.ID.Hex(), body.URL, actor)
+		if err != nil {
+			return availabilityError(c, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Calendar imported successfully", "imported": imported})
+}
+
+// managedRental loads the rental of the request and checks the authenticated user owns it or is an admin.
+// It writes the error response and returns false otherwise.
+func (h *RentalHandler) managedRental(c echo.Context) (*types.Rental, bool, error) {
+	rental, err := h.service.GetRentalByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return nil, false, c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch rental"})
+	}
+	if rental == nil {
+		return nil, false, c.JSON(http.StatusNotFound, map[string]string{"error": "Rental not found"})
+	}
+	if !canManage(c, rental) {
+		return nil, false, c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	}
+	return rental, true, nil
+}
+
+func availabilityError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrRentalNotFound), errors.Is(err, service.ErrBlockNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"server/internal/rental/service"
+	types "server/internal/rental/types"
+	"server/internal/rental/utils"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SaveDraft handles the POST request to save an incomplete rental as a draft.
+// The body is the JSON of CreateRental, but no field is required until the draft is published.
+func (h *RentalHandler) SaveDraft(c echo.Context) error {
+	userID, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var rental types.Rental
+	if err := c.Bind(&rental); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+
+	// Fields managed by the server
+	now := time.Now()
+	rental.ID = primitive.NewObjectID()
+	rental.CreatedBy = userID
+	rental.UpdatedBy = userID
+	rental.LastUpdatedBy = userID
+	rental.CreatedAt = now
+	rental.UpdatedAt = now
+	rental.DeletedAt = nil
+	rental.Version = 0
+	rental.Availability = nil
+	rental.Location, rental.Distance, rental.Score = nil, nil, nil
+
+	// Images are optional, the referenced uploads are moved into the folder of the rental
+	rentalFolder := filepath.Join(utils.GetBasePath(), rental.ID.Hex(), "images")
+	if len(rental.Images) > 0 {
+		images, err := utils.ClaimStagedImages(rental.Images, userID, rentalFolder)
+		if err != nil {
+			return claimError(c, err)
+		}
+		rental.Images = images
+	}
+
+	if err := h.service.SaveDraft(c.Request().Context(), rental); err != nil {
+		// Give the uploads back so that the draft can be sent again
+		utils.ReleaseClaimedImages(rental.Images, userID)
+		os.RemoveAll(filepath.Dir(rentalFolder))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	c.Response().Header().Set("ETag", rentalETag(0))
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Draft saved successfully", "id": rental.ID.Hex()})
+}
+
+// UpdateDraft handles the PUT request to save a new version of a draft.
+// The images of the draft are kept, those listed in the body are staged uploads added to them.
+// Saving a draft cancels its scheduled publication.
+func (h *RentalHandler) UpdateDraft(c echo.Context) error {
+	draft, ok, err := h.managedRental(c)
+	if !ok {
+		return err
+	}
+	if draft.Status != types.Draft {
+		return c.JSON(http.StatusConflict, map[string]string{"error": service.ErrNotDraft.Error()})
+	}
+	version, ok, err := requireVersion(c, draft.Version)
+	if !ok {
+		return err
+	}
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var rental types.Rental
+	if err := c.Bind(&rental); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+
+	// Fields managed by the server keep their stored value
+	rental.ID = draft.ID
+	rental.CreatedBy = draft.CreatedBy
+	rental.CreatedAt = draft.CreatedAt
+	rental.DeletedAt = nil
+	rental.Availability = draft.Availability
+	rental.Location, rental.Distance, rental.Score = nil, nil, nil
+
+	images := draft.Images
+	var claimed []string
+	if len(rental.Images) > 0 {
+		// Staged uploads belong to the user sending them, which may be an admin
+		rentalFolder := filepath.Join(utils.GetBasePath(), draft.ID.Hex(), "images")
+		claimed, err = utils.ClaimStagedImages(rental.Images, actor, rentalFolder)
+		if err != nil {
+			return claimError(c, err)
+		}
+		images = append(images, claimed...)
+	}
+	rental.Images = images
+
+	if err := h.service.UpdateDraft(c.Request().Context(), draft.ID.Hex(), rental, version, actor); err != nil {
+		utils.ReleaseClaimedImages(claimed, actor)
+		switch {
+		case errors.Is(err, service.ErrVersionConflict):
+			current, err := h.service.GetRentalByID(c.Request().Context(), draft.ID.Hex())
+			if err == nil && current != nil {
+				return versionConflict(c, current.Version)
+			}
+		case errors.Is(err, service.ErrNotDraft):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	c.Response().Header().Set("ETag", rentalETag(version+1))
+	return c.JSON(http.StatusOK, map[string]string{"message": "Draft saved successfully"})
+}
+
+// GetDrafts handles the GET request for the drafts of the authenticated user
+func (h *RentalHandler) GetDrafts(c echo.Context) error {
+	userID, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	pageRequest, err := utils.ParsePageRequest(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := h.service.GetDrafts(c.Request().Context(), userID, pageRequest)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve drafts"})
+	}
+
+	for i := range page.Items {
+		page.Items[i].Images = utils.MapImagePathsToURLs(c, page.Items[i].Images)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// PublishRental handles the POST request to submit a draft for moderation.
+// The draft must pass the validation of a new rental. With {"publishAt": "2024-06-01T08:00:00Z"}
+// it is only scheduled and gets submitted at that time, unless it is saved again before.
+func (h *RentalHandler) PublishRental(c echo.Context) error {
+	draft, ok, err := h.managedRental(c)
+	if !ok {
+		return err
+	}
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var body struct {
+		PublishAt *time.Time `json:"publishAt"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data, publishAt must be an RFC 3339 time"})
+	}
+
+	if draft.Status != types.Draft {
+		return c.JSON(http.StatusConflict, map[string]string{"error": service.ErrNotDraft.Error()})
+	}
+	if err := h.validate.Struct(draft); err != nil {
+		return validationFailed(c, err)
+	}
+
+	if err := h.service.PublishRental(c.Request().Context(), draft.ID.Hex(), body.PublishAt, actor); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRentalNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrNotDraft), errors.Is(err, service.ErrStatusConflict):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if body.PublishAt != nil && body.PublishAt.After(time.Now()) {
+		return c.JSON(http.StatusOK, map[string]string{"message": "Rental scheduled for publication", "publishAt": body.PublishAt.Format(time.RFC3339)})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Rental submitted for moderation"})
+}
+
+func claimError(c echo.Context, err error) error {
+	if errors.Is(err, utils.ErrUnknownImage) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
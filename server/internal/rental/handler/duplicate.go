@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"server/internal/rental/service"
+	"server/internal/rental/utils"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetSuspectedDuplicates handles the GET request for the pending rentals flagged as suspected duplicates.
+// Each rental lists the rentals it is linked to and why.
+func (h *RentalHandler) GetSuspectedDuplicates(c echo.Context) error {
+	pageRequest, err := utils.ParsePageRequest(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := h.service.GetSuspectedDuplicates(c.Request().Context(), pageRequest)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve suspected duplicates"})
+	}
+
+	for i := range page.Items {
+		page.Items[i].Images = utils.MapImagePathsToURLs(c, page.Items[i].Images)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// DismissDuplicate handles the DELETE request of an admin finding that two linked rentals are different flats
+func (h *RentalHandler) DismissDuplicate(c echo.Context) error {
+	adminID, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	if _, err := primitive.ObjectIDFromHex(c.Param("id")); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid Rental ID format"})
+	}
+
+	if err := h.service.DismissDuplicate(c.Request().Context(), c.Param("id"), c.Param("otherId"), adminID); err != nil {
+		return moderationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Duplicate dismissed successfully"})
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"server/internal/rental/service"
+	"server/internal/rental/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RenewRental handles the POST request of an owner renewing a published or expired rental for a full lifetime
+func (h *RentalHandler) RenewRental(c echo.Context) error {
+	rental, ok, err := h.managedRental(c)
+	if !ok {
+		return err
+	}
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	renewed, err := h.service.RenewRental(c.Request().Context(), rental.ID.Hex(), actor)
+	if err != nil {
+		return moderationError(c, err)
+	}
+
+	c.Response().Header().Set("ETag", rentalETag(renewed.Version))
+	return c.JSON(http.StatusOK, map[string]string{
+		"message":   "Rental renewed successfully",
+		"expiresAt": renewed.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// GetExpiringRentals handles the GET request for the published rentals of the authenticated user that expire soon
+func (h *RentalHandler) GetExpiringRentals(c echo.Context) error {
+	userID, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	pageRequest, err := utils.ParsePageRequest(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := h.service.GetExpiringRentals(c.Request().Context(), userID, pageRequest)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve expiring rentals"})
+	}
+
+	for i := range page.Items {
+		page.Items[i].Images = utils.MapImagePathsToURLs(c, page.Items[i].Images)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
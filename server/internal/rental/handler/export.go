@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"server/config"
+	types "server/internal/rental/types"
+	"server/internal/rental/utils"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// exportFlushEvery is the number of rentals written between two flushes of the response
+const exportFlushEvery = 100
+
+// ExportRentals handles the GET request to download the rentals matching the search parameters,
+// as CSV, GeoJSON or KML given by ?format=. Admins export every rental, landlords their own.
+// Every status is exported unless some are listed, e.g. ?status=agreed,expired.
+// The rentals are streamed as they are read, an error past the first bytes can only cut the document short.
+func (h *RentalHandler) ExportRentals(c echo.Context) error {
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = "csv"
+	}
+	contentType, ok := utils.ExportContentTypes[format]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": utils.ErrUnknownExportFormat.Error()})
+	}
+
+	query, err := utils.ParseRentalQuery(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	for _, status := range strings.Split(c.QueryParam("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			query.Status = append(query.Status, types.Status(status))
+		}
+	}
+
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+	var owner *primitive.ObjectID
+	if claims.Role != "admin" {
+		userID, err := userIDFromClaims(c)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		owner = &userID
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, contentType)
+	response.Header().Set(echo.HeaderContentDisposition, `attachment; filename="rentals.`+format+`"`)
+	encoder, err := utils.NewRentalEncoder(response, format)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	count := 0
+	err = h.service.ExportRentals(c.Request().Context(), query, owner, func(rental types.Rental) error {
+		rental.Images = utils.MapImagePathsToURLs(c, rental.Images)
+		if err := encoder.Encode(rental); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			response.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !response.Committed {
+			response.Header().Del(echo.HeaderContentType)
+			response.Header().Del(echo.HeaderContentDisposition)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to export rentals"})
+		}
+		log.Printf("Export of rentals cut short after %d rentals: %v", count, err)
+		return nil
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"server/internal/rental/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImportRentals handles the POST request of a landlord listing many rentals at once.
+// The multipart form carries a CSV or JSON Lines file under "file", its format is given by "format" or by its extension.
+// With ?dryRun=true the rows are only validated. The report gives the outcome of every row.
+func (h *RentalHandler) ImportRentals(c echo.Context) error {
+	owner, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "An import file is required"})
+	}
+	format := c.FormValue("format")
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(file.Filename), ".")
+	}
+	dryRun := c.QueryParam("dryRun") == "true"
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read import file"})
+	}
+	defer src.Close()
+
+	report, err := h.service.ImportRentals(c.Request().Context(), src, format, owner, dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImport) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"server/internal/rental/service"
+	"server/internal/rental/utils"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApproveRental handles the POST request of an admin publishing a pending rental
+func (h *RentalHandler) ApproveRental(c echo.Context) error {
+	adminID, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	if _, err := primitive.ObjectIDFromHex(c.Param("id")); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid Rental ID format"})
+	}
+
+	if err := h.service.ApproveRental(c.Request().Context(), c.Param("id"), adminID); err != nil {
+		return moderationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Rental approved successfully"})
+}
+
+// DeclineRental handles the POST request of an admin declining a rental.
+// The body must carry the reason shown to the landlord: {"reason": "..."}.
+func (h *RentalHandler) DeclineRental(c echo.Context) error {
+	adminID, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	if _, err := primitive.ObjectIDFromHex(c.Param("id")); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid Rental ID format"})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+	if body.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "A reason is required to decline a rental"})
+	}
+
+	if err := h.service.DeclineRental(c.Request().Context(), c.Param("id"), adminID, body.Reason); err != nil {
+		return moderationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Rental declined successfully"})
+}
+
+// GetPendingRentals handles the GET request for the moderation queue, oldest rentals first
+func (h *RentalHandler) GetPendingRentals(c echo.Context) error {
+	pageRequest, err := utils.ParsePageRequest(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	page, err := h.service.GetPendingRentals(c.Request().Context(), pageRequest)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve pending rentals"})
+	}
+
+	for i := range page.Items {
+		page.Items[i].Images = utils.MapImagePathsToURLs(c, page.Items[i].Images)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+func moderationError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrRentalNotFound), errors.Is(err, service.ErrNotLinked):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTransition), errors.Is(err, service.ErrStatusConflict),
+		errors.Is(err, service.ErrSuspectedDuplicate):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrReasonRequired):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update the rental status"})
+	}
+}
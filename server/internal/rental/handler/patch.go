@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"server/internal/jsonpatch"
+	"server/internal/rental/service"
+	"server/internal/rental/utils"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PatchRental handles the PATCH request to change some fields of a rental.
+// The body is a JSON merge patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json),
+// only the fields it names change and they are validated against the rules declared on the rental.
+func (h *RentalHandler) PatchRental(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid Rental ID format"})
+	}
+
+	existingRental, err := h.service.GetRentalByID(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch rental"})
+	}
+	if existingRental == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Rental not found"})
+	}
+	if !canManage(c, existingRental) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	}
+
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	version, ok, err := requireVersion(c, existingRental.Version)
+	if !ok {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read request body"})
+	}
+
+	patched, fields, err := utils.PatchRental(*existingRental, c.Request().Header.Get(echo.HeaderContentType), body)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrUnsupportedPatch):
+			return c.JSON(http.StatusUnsupportedMediaType, map[string]string{"error": err.Error()})
+		case errors.Is(err, jsonpatch.ErrTestFailed):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		default:
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	// Validate the patched fields only, the others were valid or predate the rules.
+	// The details of the rental type are checked on every patch, they must always match the type.
+	if len(fields) > 0 {
+		if err := h.validate.StructPartial(patched, fields...); err != nil {
+			return validationFailed(c, err)
+		}
+	}
+
+	if err := h.service.PatchRental(c.Request().Context(), id, *patched, version, actor); err != nil {
+		if errors.Is(err, service.ErrVersionConflict) {
+			current, err := h.service.GetRentalByID(c.Request().Context(), id)
+			if err == nil && current != nil {
+				return versionConflict(c, current.Version)
+			}
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update rental"})
+	}
+
+	c.Response().Header().Set("ETag", rentalETag(version+1))
+	return c.JSON(http.StatusOK, map[string]string{"message": "Rental updated successfully"})
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -9,12 +12,17 @@ import (
 	"sync"
 	"time"
 
+	"server/config"
+	currencyTypes "server/internal/currency/types"
+	"server/internal/geo"
 	"server/internal/rental/service"
 
 	types "server/internal/rental/types"
 	"server/internal/rental/utils"
 	userService "server/internal/user/service"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -22,20 +30,22 @@ import (
 type RentalHandler struct {
 	service     service.RentalService
 	userService userService.UserService
+	validate    *validator.Validate
 }
 
 func NewRentalHandler(service service.RentalService, userService userService.UserService) *RentalHandler {
-	return &RentalHandler{service: service, userService: userService}
+	return &RentalHandler{service: service, userService: userService, validate: utils.NewValidator()}
 }
 
-// AddRental handles adding a new rental
+// AddRental handles adding a new rental from a multipart form.
+// CreateRental is the JSON equivalent, validated against the rental struct tags.
 func (h *RentalHandler) AddRental(c echo.Context) error {
 	var rental types.Rental
 
-	// Validate user ID
-	userID, err := primitive.ObjectIDFromHex(c.FormValue("createdBy"))
+	// The landlord is the authenticated user
+	userID, err := userIDFromClaims(c)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
 	}
 
 	// Verify user exists
@@ -80,6 +90,15 @@ func (h *RentalHandler) AddRental(c echo.Context) error {
 		PartiesAllowed: c.FormValue("rules.smokingAllowed") == "true",
 	}
 
+	// Type and its details
+	rental.Type = types.RentalType(c.FormValue("type"))
+	if err := typeDetailsFromForm(c, &rental); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := h.validate.StructPartial(rental, utils.RentalFields("type", "shared", "sale", "independent")...); err != nil {
+		return validationFailed(c, err)
+	}
+
 	// Parse Tags
 	tags := c.FormValue("tags")
 	if tags != "" {
@@ -88,7 +107,13 @@ func (h *RentalHandler) AddRental(c echo.Context) error {
 
 	// Default values
 	rental.Status = types.Pending
-	rental.Currency = "TND"
+	rental.Currency = strings.ToUpper(c.FormValue("currency"))
+	if rental.Currency == "" {
+		rental.Currency = "TND"
+	}
+	if !currencyTypes.IsSupported(rental.Currency) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unsupported currency: " + rental.Currency})
+	}
 	rental.Standing = types.Standing("standing")
 	rental.CreatedBy = userID
 	rental.UpdatedBy = userID
@@ -101,6 +126,9 @@ func (h *RentalHandler) AddRental(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to parse form data"})
 	}
 
+	// The ID names the image folder, so it is assigned before the rental is stored
+	rental.ID = primitive.NewObjectID()
+
 	// Create image folder
 	rentalFolder := filepath.Join(utils.GetBasePath(), rental.ID.Hex(), "images")
 	if err := os.MkdirAll(rentalFolder, os.ModePerm); err != nil {
@@ -127,21 +155,273 @@ func (h *RentalHandler) AddRental(c echo.Context) error {
 	return c.JSON(http.StatusCreated, map[string]string{"message": "Rental added successfully"})
 }
 
-// GetAllRentals handles the GET request to retrieve all rentals
+// UploadRentalImages handles the POST request to upload the images of a rental before creating it.
+// The images are resized and staged, the returned references go in the images of the JSON body of CreateRental.
+func (h *RentalHandler) UploadRentalImages(c echo.Context) error {
+	userID, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse form data"})
+	}
+	files := form.File["images"]
+	if len(files) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one image is required"})
+	}
+
+	references := []string{}
+	for _, file := range files {
+		reference, err := utils.StageImage(file, userID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		references = append(references, reference)
+	}
+
+	return c.JSON(http.StatusCreated, map[string][]string{"images": references})
+}
+
+// CreateRental handles the POST request to create a rental from a JSON body.
+// Images are uploaded beforehand with UploadRentalImages and listed by reference.
+// The rental is validated against its struct tags, failures are reported field by field.
+func (h *RentalHandler) CreateRental(c echo.Context) error {
+	userID, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var rental types.Rental
+	if err := c.Bind(&rental); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+
+	// Fields managed by the server
+	now := time.Now()
+	rental.ID = primitive.NewObjectID()
+	rental.Status = types.Pending
+	rental.Moderation = nil
+	rental.CreatedBy = userID
+	rental.UpdatedBy = userID
+	rental.LastUpdatedBy = userID
+	rental.CreatedAt = now
+	rental.UpdatedAt = now
+	rental.DeletedAt = nil
+	rental.Version = 0
+	rental.Availability = nil
+	rental.Location, rental.Distance, rental.Score = nil, nil, nil
+
+	// Default values
+	if rental.Currency == "" {
+		rental.Currency = "TND"
+	}
+	if rental.Standing == "" {
+		rental.Standing = types.Standard
+	}
+
+	if err := h.validate.Struct(rental); err != nil {
+		return validationFailed(c, err)
+	}
+
+	// Move the referenced uploads into the folder of the rental
+	rentalFolder := filepath.Join(utils.GetBasePath(), rental.ID.Hex(), "images")
+	images, err := utils.ClaimStagedImages(rental.Images, userID, rentalFolder)
+	if err != nil {
+		return claimError(c, err)
+	}
+	rental.Images = images
+
+	if err := h.service.AddRental(c.Request().Context(), rental); err != nil {
+		// Give the uploads back so that the rental can be sent again
+		utils.ReleaseClaimedImages(images, userID)
+		os.RemoveAll(filepath.Dir(rentalFolder))
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Rental added successfully", "id": rental.ID.Hex()})
+}
+
+// typeDetailsFromForm reads the details matching the type of a rental from the form fields
+// prefixed by the type, e.g. shared.roomCount or sale.salePrice
+func typeDetailsFromForm(c echo.Context, rental *types.Rental) error {
+	var err error
+	formInt := func(name string) int64 {
+		raw := c.FormValue(name)
+		if raw == "" || err != nil {
+			return 0
+		}
+		value, parseErr := strconv.ParseInt(raw, 10, 64)
+		if parseErr != nil {
+			err = fmt.Errorf("invalid %s: must be a whole number", name)
+		}
+		return value
+	}
+
+	switch rental.Type {
+	case types.Shared:
+		rental.Shared = &types.SharedDetails{
+			RoomCount:        formInt("shared.roomCount"),
+			Occupants:        formInt("shared.occupants"),
+			GenderPreference: types.Gender(c.FormValue("shared.genderPreference")),
+		}
+		if rental.Shared.GenderPreference == "" {
+			rental.Shared.GenderPreference = types.AnyGender
+		}
+	case types.Sale:
+		rental.Sale = &types.SaleDetails{
+			SalePrice:   formInt("sale.salePrice"),
+			TitleStatus: types.TitleStatus(c.FormValue("sale.titleStatus")),
+			Negotiable:  c.FormValue("sale.negotiable") == "true",
+		}
+	case types.Independent:
+		rental.Independent = &types.IndependentDetails{
+			LeaseMonths: formInt("independent.leaseMonths"),
+			Deposit:     formInt("independent.deposit"),
+		}
+	}
+	return err
+}
+
+// validationFailed reports the validation errors of a rental by field, named by their JSON path
+func validationFailed(c echo.Context, err error) error {
+	validationErrors, ok := utils.ValidationDetails(err)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Validation failed", "details": validationErrors})
+}
+
+// GetAllRentals handles the GET request to retrieve a page of rentals, filtered by the query parameters
 func (h *RentalHandler) GetAllRentals(c echo.Context) error {
-	// Fetch all rentals
-	rentals, err := h.service.GetAllRentals(c.Request().Context())
+	// Parse search filters and pagination
+	query, pageRequest, err := utils.ParseSearch(c.QueryParams())
 	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return h.searchRentals(c, query, pageRequest)
+}
+
+// NearRentals handles the GET request to retrieve rentals around a point, closest first
+func (h *RentalHandler) NearRentals(c echo.Context) error {
+	if c.QueryParam("lat") == "" || c.QueryParam("lng") == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "lat and lng are required"})
+	}
+
+	// Default to distance ordering unless the client asks for another one or searches text
+	values := c.QueryParams()
+	if values.Get("sort") == "" && values.Get("q") == "" {
+		values.Set("sort", string(types.SortByDistance))
+	}
+
+	query, pageRequest, err := utils.ParseSearch(values)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return h.searchRentals(c, query, pageRequest)
+}
+
+// searchRentals fetches a page of rentals and writes it as the response
+func (h *RentalHandler) searchRentals(c echo.Context, query types.RentalQuery, pageRequest types.PageRequest) error {
+	page, err := h.service.GetAllRentals(c.Request().Context(), query, pageRequest)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrPriceConversion) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve rentals"})
 	}
 
 	// Convert image file paths to public URLs for each rental
-	for i := range rentals {
-		rentals[i].Images = utils.MapImagePathsToURLs(c, rentals[i].Images)
+	for i := range page.Items {
+		page.Items[i].Images = utils.MapImagePathsToURLs(c, page.Items[i].Images)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetRentalMarkers handles the request to retrieve the markers inside a map viewport.
+// GET takes the viewport as a bbox query parameter, POST takes a GeoJSON polygon drawn by the user:
+// {"polygon": {"type": "Polygon", "coordinates": [...]}}. Both accept the list filters as query parameters.
+func (h *RentalHandler) GetRentalMarkers(c echo.Context) error {
+	query, err := utils.ParseRentalQuery(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if c.Request().Method == http.MethodPost {
+		var body struct {
+			Polygon *geo.Polygon `json:"polygon"`
+		}
+		if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil || body.Polygon == nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "A GeoJSON polygon is required"})
+		}
+		if err := body.Polygon.Validate(); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		query.Within = body.Polygon
+	}
+
+	if query.Within == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "bbox is required"})
+	}
+
+	markers, err := h.service.GetRentalMarkers(c.Request().Context(), query)
+	if err != nil {
+		if errors.Is(err, service.ErrPriceConversion) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve markers"})
+	}
+
+	return c.JSON(http.StatusOK, markers)
+}
+
+// GetRentalClusters handles the GET request to retrieve the marker clusters of a viewport at a zoom level
+func (h *RentalHandler) GetRentalClusters(c echo.Context) error {
+	query, err := utils.ParseRentalQuery(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if query.Within == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "bbox is required"})
 	}
 
-	// Return the modified rentals
-	return c.JSON(http.StatusOK, rentals)
+	zoom, err := strconv.Atoi(c.QueryParam("zoom"))
+	if err != nil || zoom < 0 || zoom > types.MaxClusterZoom {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("zoom must be an integer between 0 and %d", types.MaxClusterZoom)})
+	}
+
+	clusters, err := h.service.GetRentalClusters(c.Request().Context(), query, zoom)
+	if err != nil {
+		if errors.Is(err, service.ErrPriceConversion) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve clusters"})
+	}
+
+	return c.JSON(http.StatusOK, clusters)
+}
+
+// GetRentalFacets handles the GET request to count the rentals per filter option.
+// It accepts the same filters as the list endpoint.
+func (h *RentalHandler) GetRentalFacets(c echo.Context) error {
+	query, err := utils.ParseRentalQuery(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	facets, err := h.service.GetRentalFacets(c.Request().Context(), query)
+	if err != nil {
+		if errors.Is(err, service.ErrPriceConversion) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve facets"})
+	}
+
+	return c.JSON(http.StatusOK, facets)
 }
 
 func (h *RentalHandler) GetRentalByID(c echo.Context) error {
@@ -157,10 +437,21 @@ func (h *RentalHandler) GetRentalByID(c echo.Context) error {
 	if rental == nil {
 		return c.JSON(http.StatusOK, map[string]string{"message": "Rental not found", "status": "empty"})
 	}
+	if rental.Status != types.Agreed && !isOwnerOrAdmin(c, rental.CreatedBy.Hex()) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Rental not found"})
+	}
 
 	// Convert image file paths to public URLs using the helper
 	rental.Images = utils.MapImagePathsToURLs(c, rental.Images)
 
+	// Convert the price when the client displays prices in another currency, e.g. ?currency=EUR
+	rentals := []types.Rental{*rental}
+	if err := h.service.ConvertPrices(c.Request().Context(), strings.ToUpper(c.QueryParam("currency")), rentals); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	rental = &rentals[0]
+
+	c.Response().Header().Set("ETag", rentalETag(rental.Version))
 	return c.JSON(http.StatusOK, rental)
 }
 
@@ -184,11 +475,31 @@ func (h *RentalHandler) UpdateRental(c echo.Context) error {
 	if existingRental == nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Rental not found"})
 	}
+	if !canManage(c, existingRental) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	}
+
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	// The changes must be based on the current version, otherwise they would overwrite someone else's
+	version, ok, err := requireVersion(c, existingRental.Version)
+	if !ok {
+		return err
+	}
 
 	// Update rental fields from form input
 	existingRental.Name = c.FormValue("name")
 	existingRental.Description = c.FormValue("description")
 	existingRental.Price, _ = strconv.ParseInt(c.FormValue("price"), 10, 64)
+	if currency := strings.ToUpper(c.FormValue("currency")); currency != "" {
+		if !currencyTypes.IsSupported(currency) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unsupported currency: " + currency})
+		}
+		existingRental.Currency = currency
+	}
 	existingRental.Bedrooms, _ = strconv.ParseInt(c.FormValue("bedrooms"), 10, 64)
 	existingRental.Bathrooms, _ = strconv.ParseInt(c.FormValue("bathrooms"), 10, 64)
 	existingRental.AreaSize, _ = strconv.ParseInt(c.FormValue("areaSize"), 10, 64)
@@ -220,12 +531,21 @@ func (h *RentalHandler) UpdateRental(c echo.Context) error {
 		existingRental.Tags = strings.Split(tags, ",")
 	}
 
-	// Update standing and status if provided
+	// Update standing if provided, the status only changes through moderation
 	if standing := c.FormValue("standing"); standing != "" {
 		existingRental.Standing = types.Standing(standing)
 	}
-	if status := c.FormValue("status"); status != "" {
-		existingRental.Status = types.Status(status)
+
+	// Update the type if provided, its details replace those of the previous type
+	if rentalType := c.FormValue("type"); rentalType != "" {
+		existingRental.Type = types.RentalType(rentalType)
+		existingRental.Shared, existingRental.Sale, existingRental.Independent = nil, nil, nil
+		if err := typeDetailsFromForm(c, existingRental); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if err := h.validate.StructPartial(existingRental, utils.RentalFields("type", "shared", "sale", "independent")...); err != nil {
+			return validationFailed(c, err)
+		}
 	}
 
 	// Handle new image uploads
@@ -248,31 +568,144 @@ func (h *RentalHandler) UpdateRental(c echo.Context) error {
 		}
 	}
 
-	// Update the `UpdatedAt` timestamp
+	// Update the `UpdatedAt` timestamp and the editor
 	existingRental.UpdatedAt = time.Now()
+	existingRental.UpdatedBy = actor
+	existingRental.LastUpdatedBy = actor
 
 	// Call the service to update the rental
-	if err := h.service.UpdateRental(c.Request().Context(), objectID.Hex(), *existingRental); err != nil {
+	if err := h.service.UpdateRental(c.Request().Context(), objectID.Hex(), *existingRental, version, actor); err != nil {
+		if errors.Is(err, service.ErrVersionConflict) {
+			current, err := h.service.GetRentalByID(c.Request().Context(), objectID.Hex())
+			if err == nil && current != nil {
+				return versionConflict(c, current.Version)
+			}
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update rental"})
 	}
 
+	c.Response().Header().Set("ETag", rentalETag(version+1))
 	return c.JSON(http.StatusOK, map[string]string{"message": "Rental updated successfully"})
 }
 
-// DeleteRental handles the DELETE request to remove a rental
+// DeleteRental handles the DELETE request to soft delete a rental. Only its owner or an admin can delete it.
 func (h *RentalHandler) DeleteRental(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Rental ID is required"})
 	}
 
-	if err := h.service.DeleteRental(c.Request().Context(), id); err != nil {
+	rental, err := h.service.GetRentalByID(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch rental"})
+	}
+	if rental == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Rental not found"})
+	}
+	if !canManage(c, rental) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	}
+
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	if err := h.service.DeleteRental(c.Request().Context(), id, actor); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete rental"})
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Rental deleted successfully"})
 }
 
+// RestoreRental handles the POST request to bring back a soft deleted rental. Only its owner or an admin can restore it.
+func (h *RentalHandler) RestoreRental(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Rental ID is required"})
+	}
+
+	rental, err := h.service.GetDeletedRentalByID(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch rental"})
+	}
+	if rental == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Deleted rental not found"})
+	}
+	if !canManage(c, rental) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	}
+
+	actor, err := userIDFromClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	if err := h.service.RestoreRental(c.Request().Context(), id, actor); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to restore rental"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Rental restored successfully"})
+}
+
+// canManage reports whether the authenticated user owns the rental or is an admin
+func canManage(c echo.Context, rental *types.Rental) bool {
+	return isOwnerOrAdmin(c, rental.CreatedBy.Hex())
+}
+
+// isOwnerOrAdmin reports whether the request is made by the user with the given ID or by an admin.
+// Anonymous requests, on the routes that allow them, are neither.
+func isOwnerOrAdmin(c echo.Context, ownerID string) bool {
+	user, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return false
+	}
+	claims, ok := user.Claims.(*config.JWTClaims)
+	return ok && (claims.Role == "admin" || claims.UserID == ownerID)
+}
+
+// userIDFromClaims returns the ID of the authenticated user
+func userIDFromClaims(c echo.Context) (primitive.ObjectID, error) {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("invalid user ID in token")
+	}
+	return userID, nil
+}
+
+// GetRentalHistory handles the GET request to retrieve the change history of a rental.
+// Only its owner or an admin can read it, including after the rental was deleted.
+func (h *RentalHandler) GetRentalHistory(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid Rental ID format"})
+	}
+
+	rental, err := h.service.GetRentalByID(c.Request().Context(), id)
+	if err == nil && rental == nil {
+		rental, err = h.service.GetDeletedRentalByID(c.Request().Context(), id)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch rental"})
+	}
+	if rental == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Rental not found"})
+	}
+	if !canManage(c, rental) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Access denied"})
+	}
+
+	history, err := h.service.GetRentalHistory(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch rental history"})
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
 // GetRentalsByUserID handles the GET request to retrieve rentals by a specific user ID
 func (h *RentalHandler) GetRentalsByUserID(c echo.Context) error {
 	userID := c.Param("id")
@@ -286,16 +719,30 @@ func (h *RentalHandler) GetRentalsByUserID(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid User ID format"})
 	}
 
+	pageRequest, err := utils.ParsePageRequest(c.QueryParams())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
 	// Fetch rentals for the user
-	rentals, err := h.service.GetRentalsByUserID(c.Request().Context(), userID)
+	// Other users only see the published rentals
+	publishedOnly := !isOwnerOrAdmin(c, userID)
+	page, err := h.service.GetRentalsByUserID(c.Request().Context(), userID, publishedOnly, pageRequest)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
 	// Convert image file paths to public URLs using the helper
-	for i := range rentals {
-		rentals[i].Images = utils.MapImagePathsToURLs(c, rentals[i].Images)
+	for i := range page.Items {
+		page.Items[i].Images = utils.MapImagePathsToURLs(c, page.Items[i].Images)
+	}
+
+	if err := h.service.ConvertPrices(c.Request().Context(), strings.ToUpper(c.QueryParam("currency")), page.Items); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	return c.JSON(http.StatusOK, rentals)
+	return c.JSON(http.StatusOK, page)
 }
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server/internal/rental/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// rentalETag formats the version of a rental as a strong entity tag
+func rentalETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch reads the rental version from an If-Match header value
+func parseIfMatch(header string) (int64, error) {
+	tag := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, errors.New("malformed If-Match header")
+	}
+	return strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+}
+
+// requireVersion checks the If-Match header of a write against the current version of the rental.
+// It writes the error response and returns false when the write must not go ahead.
+func requireVersion(c echo.Context, current int64) (int64, bool, error) {
+	header := c.Request().Header.Get("If-Match")
+	if header == "" {
+		return 0, false, c.JSON(http.StatusPreconditionRequired, map[string]string{"error": "The If-Match header is required, send the ETag of the rental"})
+	}
+
+	version, err := parseIfMatch(header)
+	if err != nil || version != current {
+		return 0, false, versionConflict(c, current)
+	}
+	return version, true, nil
+}
+
+// versionConflict answers a write based on an outdated version with the current one
+func versionConflict(c echo.Context, current int64) error {
+	c.Response().Header().Set("ETag", rentalETag(current))
+	return c.JSON(http.StatusPreconditionFailed, map[string]interface{}{
+		"error":   service.ErrVersionConflict.Error(),
+		"version": current,
+	})
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepository interface {
+	AddEntry(ctx context.Context, entry types.AuditEntry) error
+	GetEntriesByRentalID(ctx context.Context, rentalID primitive.ObjectID) ([]types.AuditEntry, error)
+}
+
+type auditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(db *mongo.Database) AuditRepository {
+	return &auditRepository{
+		collection: db.Collection("rental_audit"),
+	}
+}
+
+// AddEntry stores an audit entry
+func (r *auditRepository) AddEntry(ctx context.Context, entry types.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	entry.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, entry); err != nil {
+		log.Printf("Error inserting audit entry: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetEntriesByRentalID retrieves the history of a rental, oldest entry first
+func (r *auditRepository) GetEntriesByRentalID(ctx context.Context, rentalID primitive.ObjectID) ([]types.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"rentalId": rentalID}, opts)
+	if err != nil {
+		log.Printf("Error finding audit entries: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []types.AuditEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		log.Printf("Error decoding audit entries: %v", err)
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrBlockNotFound is returned when a rental has no availability block with the given ID
+	ErrBlockNotFound = errors.New("no availability block found with the given ID")
+	// ErrPeriodUnavailable is returned when a period overlaps a block of the rental calendar
+	ErrPeriodUnavailable = errors.New("the rental is not available over this period")
+)
+
+// AddAvailabilityBlock appends a block to the calendar of a rental
+func (r *rentalRepository) AddAvailabilityBlock(ctx context.Context, id string, block types.AvailabilityBlock) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	update := bson.M{
+		"$push": bson.M{"availability": block},
+		"$set":  bson.M{"updatedAt": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID, "deletedAt": nil}, update)
+	if err != nil {
+		log.Printf("Error adding availability block: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("no rental found with the given ID")
+	}
+
+	return nil
+}
+
+// BookPeriod appends a block to the calendar of a rental only if it overlaps none of the existing blocks.
+// The check and the write happen in a single update, so two overlapping bookings cannot both succeed.
+func (r *rentalRepository) BookPeriod(ctx context.Context, id string, block types.AvailabilityBlock) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	filter := bson.M{
+		"_id":       objectID,
+		"deletedAt": nil,
+		"availability": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"start": bson.M{"$lt": block.End},
+			"end":   bson.M{"$gt": block.Start},
+		}}},
+	}
+	update := bson.M{
+		"$push": bson.M{"availability": block},
+		"$set":  bson.M{"updatedAt": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error booking period: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID, "deletedAt": nil})
+		if err != nil {
+			log.Printf("Error checking rental existence: %v", err)
+			return err
+		}
+		if count == 0 {
+			return errors.New("no rental found with the given ID")
+		}
+		return ErrPeriodUnavailable
+	}
+
+	return nil
+}
+
+// RemoveAvailabilityBlock removes a block from the calendar of a rental
+func (r *rentalRepository) RemoveAvailabilityBlock(ctx context.Context, id string, blockID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	filter := bson.M{"_id": objectID, "deletedAt": nil, "availability._id": blockID}
+	update := bson.M{
+		"$pull": bson.M{"availability": bson.M{"_id": blockID}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+		"$inc":  bson.M{"version": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error removing availability block: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrBlockNotFound
+	}
+
+	return nil
+}
+
+// ReplaceSourceBlocks swaps the blocks imported from a calendar source for a new set, in a single update.
+// The blocks of the other sources are left untouched.
+func (r *rentalRepository) ReplaceSourceBlocks(ctx context.Context, id string, source string, blocks []types.AvailabilityBlock) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+	if blocks == nil {
+		blocks = []types.AvailabilityBlock{}
+	}
+
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"availability": bson.M{"$concatArrays": bson.A{
+			bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$availability", bson.A{}}},
+				"as":    "block",
+				"cond":  bson.M{"$ne": bson.A{"$$block.source", source}},
+			}},
+			blocks,
+		}},
+		"updatedAt": time.Now(),
+		"version":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+	}}}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID, "deletedAt": nil}, update)
+	if err != nil {
+		log.Printf("Error replacing imported availability blocks: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("no rental found with the given ID")
+	}
+
+	return nil
+}
+
+// AddCalendarFeed registers a feed for periodic sync, a feed already registered is left as it is
+func (r *rentalRepository) AddCalendarFeed(ctx context.Context, id string, feed types.CalendarFeed) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	filter := bson.M{"_id": objectID, "calendarFeeds.url": bson.M{"$ne": feed.URL}}
+	if _, err := r.collection.UpdateOne(ctx, filter, bson.M{"$push": bson.M{"calendarFeeds": feed}}); err != nil {
+		log.Printf("Error adding calendar feed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// RecordFeedSync stores the outcome of the last sync of a feed, syncErr being empty when it succeeded
+func (r *rentalRepository) RecordFeedSync(ctx context.Context, id primitive.ObjectID, url string, at time.Time, syncErr string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "calendarFeeds.url": url}
+	update := bson.M{"$set": bson.M{
+		"calendarFeeds.$.syncedAt": at,
+		"calendarFeeds.$.error":    syncErr,
+	}}
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		log.Printf("Error recording calendar feed sync: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetRentalsWithFeeds retrieves the rentals that sync calendar feeds, with their feeds only
+func (r *rentalRepository) GetRentalsWithFeeds(ctx context.Context) ([]types.Rental, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"calendarFeeds.0": bson.M{"$exists": true}, "deletedAt": nil}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"calendarFeeds": 1}))
+	if err != nil {
+		log.Printf("Error finding rentals with calendar feeds: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rentals := []types.Rental{}
+	if err = cursor.All(ctx, &rentals); err != nil {
+		log.Printf("Error decoding rentals with calendar feeds: %v", err)
+		return nil, err
+	}
+	return rentals, nil
+}
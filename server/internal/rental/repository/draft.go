@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetDraftsByUserID retrieves one page of the drafts of a user
+func (r *rentalRepository) GetDraftsByUserID(ctx context.Context, userID primitive.ObjectID, page types.PageRequest) (*types.RentalPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.paginate(ctx, types.RentalQuery{}, bson.M{"createdBy": userID, "status": types.Draft}, page)
+	if err != nil {
+		log.Printf("Error finding drafts: %v", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SchedulePublish sets the time a draft gets published, or cancels the scheduled publication when at is nil
+func (r *rentalRepository) SchedulePublish(ctx context.Context, id string, at *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	update := bson.M{"$unset": bson.M{"publishAt": ""}, "$inc": bson.M{"version": 1}}
+	if at != nil {
+		update = bson.M{"$set": bson.M{"publishAt": *at}, "$inc": bson.M{"version": 1}}
+	}
+
+	filter := bson.M{"_id": objectID, "status": types.Draft, "deletedAt": nil}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error scheduling rental publication: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+// PublishDraft submits a draft for moderation and clears its scheduled publication
+func (r *rentalRepository) PublishDraft(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	filter := bson.M{"_id": objectID, "status": types.Draft, "deletedAt": nil}
+	update := bson.M{
+		"$set":   bson.M{"status": types.Pending, "updatedAt": time.Now()},
+		"$unset": bson.M{"publishAt": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error publishing draft: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+// GetDueDrafts retrieves the drafts whose scheduled publication is at or before now, oldest first
+func (r *rentalRepository) GetDueDrafts(ctx context.Context, now time.Time) ([]types.Rental, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"status": types.Draft, "deletedAt": nil, "publishAt": bson.M{"$lte": now}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "publishAt", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding due drafts: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rentals := []types.Rental{}
+	if err = cursor.All(ctx, &rentals); err != nil {
+		log.Printf("Error decoding due drafts: %v", err)
+		return nil, err
+	}
+
+	return rentals, nil
+}
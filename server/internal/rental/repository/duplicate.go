@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"server/internal/geo"
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotLinked is returned when two rentals are not linked as suspected duplicates
+var ErrNotLinked = errors.New("rentals are not linked as suspected duplicates")
+
+// maxDuplicateCandidates bounds the rentals compared with a new one, once narrowed down to the same place or pictures
+const maxDuplicateCandidates = 50
+
+// FindDuplicateCandidates retrieves the rentals that could be the same flat as the given one:
+// those with as many bedrooms at the same normalised address or within DuplicateRadius,
+// and those whose image hashes share MinSharedHashBands bands, which near copies of a picture do.
+// Drafts and deleted rentals are left out.
+func (r *rentalRepository) FindDuplicateCandidates(ctx context.Context, rental types.Rental) ([]types.Rental, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	setLocation(&rental)
+	setDuplicateKeys(&rental)
+	var samePlace bson.A
+	if rental.AddressKey != "" {
+		samePlace = append(samePlace, bson.M{"bedrooms": rental.Bedrooms, "addressKey": rental.AddressKey})
+	}
+	if rental.Location != nil {
+		samePlace = append(samePlace, bson.M{"bedrooms": rental.Bedrooms, "location": bson.M{"$geoWithin": bson.M{
+			"$centerSphere": bson.A{rental.Location.Coordinates, types.DuplicateRadius / geo.EarthRadius},
+		}}})
+	}
+
+	candidates := append(bson.A{}, samePlace...)
+	if len(rental.ImageBands) > 0 {
+		candidates = append(candidates, bson.M{"imageBands": bson.M{"$in": rental.ImageBands}})
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"_id":       bson.M{"$ne": rental.ID},
+			"status":    bson.M{"$ne": types.Draft},
+			"deletedAt": nil,
+			"$or":       candidates,
+		}}},
+	}
+	if len(rental.ImageBands) > 0 {
+		// A single band in common is mostly chance, keep the rentals sharing enough of them
+		pipeline = append(pipeline,
+			bson.D{{Key: "$addFields", Value: bson.M{"sharedBands": bson.M{"$size": bson.M{
+				"$setIntersection": bson.A{bson.M{"$ifNull": bson.A{"$imageBands", bson.A{}}}, rental.ImageBands},
+			}}}}},
+			bson.D{{Key: "$match", Value: bson.M{
+				"$or": append(samePlace, bson.M{"sharedBands": bson.M{"$gte": types.MinSharedHashBands}}),
+			}}},
+		)
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$limit", Value: maxDuplicateCandidates}})
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Error finding duplicate candidates: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rentals := []types.Rental{}
+	if err = cursor.All(ctx, &rentals); err != nil {
+		log.Printf("Error decoding duplicate candidates: %v", err)
+		return nil, err
+	}
+
+	return rentals, nil
+}
+
+// LinkDuplicates records the suspected duplicates of a rental, and the rental as a suspected duplicate of each of them.
+// Rentals already linked are left as they are. The version is left alone, the listings themselves did not change.
+func (r *rentalRepository) LinkDuplicates(ctx context.Context, id primitive.ObjectID, links []types.DuplicateLink) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var writes []mongo.WriteModel
+	for _, link := range links {
+		writes = append(writes,
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": id, "duplicates.rentalId": bson.M{"$ne": link.RentalID}}).
+				SetUpdate(bson.M{"$push": bson.M{"duplicates": link}}),
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": link.RentalID, "duplicates.rentalId": bson.M{"$ne": id}}).
+				SetUpdate(bson.M{"$push": bson.M{"duplicates": types.DuplicateLink{
+					RentalID:   id,
+					Reasons:    link.Reasons,
+					DetectedAt: link.DetectedAt,
+				}}}),
+		)
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	if _, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		log.Printf("Error linking duplicate rentals: %v", err)
+		return err
+	}
+	return nil
+}
+
+// UnlinkDuplicates removes the link between two rentals, both ways
+func (r *rentalRepository) UnlinkDuplicates(ctx context.Context, id, otherID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"duplicates": bson.M{"rentalId": otherID}}})
+	if err != nil {
+		log.Printf("Error unlinking duplicate rentals: %v", err)
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrNotLinked
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": otherID}, bson.M{"$pull": bson.M{"duplicates": bson.M{"rentalId": id}}}); err != nil {
+		log.Printf("Error unlinking duplicate rentals: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetSuspectedDuplicates retrieves one page of the pending rentals linked to suspected duplicates
+func (r *rentalRepository) GetSuspectedDuplicates(ctx context.Context, page types.PageRequest) (*types.RentalPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	scope := bson.M{"status": types.Pending, "duplicates.0": bson.M{"$exists": true}}
+	result, err := r.paginate(ctx, types.RentalQuery{}, scope, page)
+	if err != nil {
+		log.Printf("Error finding suspected duplicates: %v", err)
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetExpiredRentals retrieves the published rentals whose expiry date is at or before now
+func (r *rentalRepository) GetExpiredRentals(ctx context.Context, now time.Time) ([]types.Rental, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"status": types.Agreed, "deletedAt": nil, "expiresAt": bson.M{"$lte": now}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		log.Printf("Error finding expired rentals: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rentals := []types.Rental{}
+	if err = cursor.All(ctx, &rentals); err != nil {
+		log.Printf("Error decoding expired rentals: %v", err)
+		return nil, err
+	}
+
+	return rentals, nil
+}
+
+// ExpireRental takes a published rental out of search.
+// The update only applies if the rental was not renewed in the meantime, ErrStatusConflict is returned otherwise.
+func (r *rentalRepository) ExpireRental(ctx context.Context, id string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	filter := bson.M{"_id": objectID, "status": types.Agreed, "deletedAt": nil, "expiresAt": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"status": types.Expired, "updatedAt": now}, "$inc": bson.M{"version": 1}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error expiring rental: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+// GetExpiringRentals retrieves one page of the published rentals of a user expiring at or before the given time
+func (r *rentalRepository) GetExpiringRentals(ctx context.Context, userID primitive.ObjectID, before time.Time, page types.PageRequest) (*types.RentalPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	scope := bson.M{"createdBy": userID, "status": types.Agreed, "expiresAt": bson.M{"$lte": before}}
+	result, err := r.paginate(ctx, types.RentalQuery{}, scope, page)
+	if err != nil {
+		log.Printf("Error finding expiring rentals: %v", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// BackfillExpiry gives the published rentals approved before listings expired an expiry date,
+// a full lifetime for their type counted from the given time. The version is left alone, the listing itself did not change.
+func (r *rentalRepository) BackfillExpiry(ctx context.Context, policy types.ExpiryPolicy, from time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	branches := bson.A{}
+	for rentalType := range policy.Lifetimes {
+		branches = append(branches, bson.M{
+			"case": bson.M{"$eq": bson.A{"$type", rentalType}},
+			"then": policy.ExpiresAt(rentalType, from),
+		})
+	}
+
+	var expiresAt interface{} = from.Add(types.DefaultLifetime)
+	if len(branches) > 0 {
+		expiresAt = bson.M{"$switch": bson.M{"branches": branches, "default": expiresAt}}
+	}
+
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{"expiresAt": expiresAt}}}}
+	filter := bson.M{"status": types.Agreed, "deletedAt": nil, "expiresAt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error backfilling rental expiry dates: %v", err)
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
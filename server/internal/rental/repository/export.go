@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ForEachRental calls fn with every rental matching the query, oldest first, as they come from the cursor.
+// The rentals are restricted to those of owner unless it is nil. Iteration stops at the first error fn returns.
+// No timeout is applied, the caller's context bounds the iteration.
+func (r *rentalRepository) ForEachRental(ctx context.Context, query types.RentalQuery, owner *primitive.ObjectID, fn func(types.Rental) error) error {
+	scope := bson.M{}
+	if owner != nil {
+		scope["createdBy"] = *owner
+	}
+	filter := and(scope, buildRentalFilter(query))
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(200)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Error finding rentals to export: %v", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var rental types.Rental
+		if err := cursor.Decode(&rental); err != nil {
+			log.Printf("Error decoding rental: %v", err)
+			return err
+		}
+		if err := fn(rental); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
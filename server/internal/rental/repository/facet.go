@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// facetGroup is one counted option of a facet
+type facetGroup struct {
+	ID    interface{} `bson:"_id"`
+	Count int64       `bson:"count"`
+}
+
+// GetRentalFacets counts the rentals per filter option in a single $facet aggregation.
+// Filters that have no facet (text, area, rules, ...) are applied to every count in the first stage;
+// each facet then applies the remaining filters except its own.
+func (r *rentalRepository) GetRentalFacets(ctx context.Context, query types.RentalQuery) (*types.RentalFacets, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Split the query into the faceted filters and the others
+	faceted := types.RentalQuery{
+		MinPrice:    query.MinPrice,
+		MaxPrice:    query.MaxPrice,
+		MinBedrooms: query.MinBedrooms,
+		MaxBedrooms: query.MaxBedrooms,
+		Standing:    query.Standing,
+		Type:        query.Type,
+		Amenities:   query.Amenities,
+	}
+	base := query
+	base.MinPrice, base.MaxPrice = nil, nil
+	base.MinBedrooms, base.MaxBedrooms = nil, nil
+	base.Standing, base.Type = nil, nil
+	base.Amenities = types.AmenitiesQuery{}
+
+	without := func(clear func(q *types.RentalQuery)) bson.D {
+		q := faceted
+		clear(&q)
+		return bson.D{{Key: "$match", Value: buildRentalFilter(q)}}
+	}
+	count := bson.D{{Key: "$group", Value: bson.M{"_id": nil, "count": bson.M{"$sum": 1}}}}
+
+	priceBoundaries := bson.A{}
+	for _, bound := range types.PriceBuckets {
+		priceBoundaries = append(priceBoundaries, bound)
+	}
+
+	facets := bson.M{
+		"standing": bson.A{
+			without(func(q *types.RentalQuery) { q.Standing = nil }),
+			bson.D{{Key: "$group", Value: bson.M{"_id": "$standing", "count": bson.M{"$sum": 1}}}},
+		},
+		"type": bson.A{
+			without(func(q *types.RentalQuery) { q.Type = nil }),
+			bson.D{{Key: "$group", Value: bson.M{"_id": "$type", "count": bson.M{"$sum": 1}}}},
+		},
+		"bedrooms": bson.A{
+			without(func(q *types.RentalQuery) { q.MinBedrooms, q.MaxBedrooms = nil, nil }),
+			bson.D{{Key: "$group", Value: bson.M{
+				"_id": bson.M{"$cond": bson.A{
+					bson.M{"$gte": bson.A{"$bedrooms", 5}}, "5+", bson.M{"$toString": "$bedrooms"},
+				}},
+				"count": bson.M{"$sum": 1},
+			}}},
+		},
+		"price": bson.A{
+			without(func(q *types.RentalQuery) { q.MinPrice, q.MaxPrice = nil, nil }),
+			bson.D{{Key: "$bucket", Value: bson.M{
+				"groupBy":    "$priceTND",
+				"boundaries": append(priceBoundaries, int64(1)<<62),
+				"default":    "other",
+				"output":     bson.M{"count": bson.M{"$sum": 1}},
+			}}},
+		},
+	}
+
+	amenities := map[string]func(q *types.AmenitiesQuery){
+		"airConditioning": func(q *types.AmenitiesQuery) { q.AirConditioning = nil },
+		"heating":         func(q *types.AmenitiesQuery) { q.Heating = nil },
+		"refrigerator":    func(q *types.AmenitiesQuery) { q.Refrigerator = nil },
+		"parking":         func(q *types.AmenitiesQuery) { q.Parking = nil },
+	}
+	for name, clear := range amenities {
+		clear := clear
+		facets["amenity_"+name] = bson.A{
+			without(func(q *types.RentalQuery) { clear(&q.Amenities) }),
+			bson.D{{Key: "$match", Value: bson.M{"amenities." + name: true}}},
+			count,
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: buildRentalFilter(base)}},
+		{{Key: "$facet", Value: facets}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Error computing rental facets: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []map[string][]facetGroup
+	if err = cursor.All(ctx, &results); err != nil {
+		log.Printf("Error decoding rental facets: %v", err)
+		return nil, err
+	}
+	result := map[string][]facetGroup{}
+	if len(results) > 0 {
+		result = results[0]
+	}
+
+	facetsResult := &types.RentalFacets{
+		Standing:  toFacetCounts(result["standing"]),
+		Type:      toFacetCounts(result["type"]),
+		Bedrooms:  toFacetCounts(result["bedrooms"]),
+		Price:     priceFacetCounts(result["price"]),
+		Amenities: map[string]int64{},
+	}
+	for name := range amenities {
+		var total int64
+		for _, group := range result["amenity_"+name] {
+			total += group.Count
+		}
+		facetsResult.Amenities[name] = total
+	}
+
+	return facetsResult, nil
+}
+
+func toFacetCounts(groups []facetGroup) []types.FacetCount {
+	counts := make([]types.FacetCount, 0, len(groups))
+	for _, group := range groups {
+		if group.ID == nil {
+			continue
+		}
+		counts = append(counts, types.FacetCount{Value: fmt.Sprint(group.ID), Count: group.Count})
+	}
+	return counts
+}
+
+// priceFacetCounts labels the price buckets with their range, e.g. "500-1000" or "3000+"
+func priceFacetCounts(groups []facetGroup) []types.FacetCount {
+	byLowerBound := map[int64]int64{}
+	for _, group := range groups {
+		switch bound := group.ID.(type) {
+		case int32:
+			byLowerBound[int64(bound)] = group.Count
+		case int64:
+			byLowerBound[bound] = group.Count
+		}
+	}
+
+	counts := make([]types.FacetCount, 0, len(types.PriceBuckets))
+	for i, lower := range types.PriceBuckets {
+		label := fmt.Sprintf("%d+", lower)
+		if i+1 < len(types.PriceBuckets) {
+			label = fmt.Sprintf("%d-%d", lower, types.PriceBuckets[i+1])
+		}
+		counts = append(counts, types.FacetCount{Value: label, Count: byLowerBound[lower]})
+	}
+	return counts
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"server/internal/geo"
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// buildRentalFilter translates a RentalQuery into a MongoDB filter document.
+// Soft deleted rentals never match.
+func buildRentalFilter(query types.RentalQuery) bson.M {
+	filter := bson.M{"deletedAt": nil}
+
+	if query.Text != "" {
+		filter["$text"] = bson.M{"$search": query.Text}
+	}
+
+	addRange(filter, "priceTND", query.MinPrice, query.MaxPrice)
+	addRange(filter, "bedrooms", query.MinBedrooms, query.MaxBedrooms)
+	addRange(filter, "bathrooms", query.MinBathrooms, query.MaxBathrooms)
+	addRange(filter, "areaSize", query.MinAreaSize, query.MaxAreaSize)
+	addRange(filter, "shared.roomCount", query.Shared.MinRoomCount, query.Shared.MaxRoomCount)
+	addRange(filter, "shared.occupants", nil, query.Shared.MaxOccupants)
+	addRange(filter, "sale.salePrice", query.Sale.MinSalePrice, query.Sale.MaxSalePrice)
+	addRange(filter, "independent.leaseMonths", query.Independent.MinLeaseMonths, query.Independent.MaxLeaseMonths)
+	addRange(filter, "independent.deposit", nil, query.Independent.MaxDeposit)
+
+	if len(query.Status) > 0 {
+		filter["status"] = bson.M{"$in": query.Status}
+	}
+	if len(query.Standing) > 0 {
+		filter["standing"] = bson.M{"$in": query.Standing}
+	}
+	if len(query.Type) > 0 {
+		filter["type"] = bson.M{"$in": query.Type}
+	}
+	if len(query.Shared.GenderPreference) > 0 {
+		filter["shared.genderPreference"] = bson.M{"$in": query.Shared.GenderPreference}
+	}
+	if len(query.Sale.TitleStatus) > 0 {
+		filter["sale.titleStatus"] = bson.M{"$in": query.Sale.TitleStatus}
+	}
+	if len(query.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": query.Tags}
+	}
+	if query.AvailableFrom != nil {
+		filter["availableFrom"] = bson.M{"$lte": *query.AvailableFrom}
+	}
+	if query.AvailableBetween != nil {
+		// No block may overlap the period, and the rental must be on the market when it starts
+		filter["availability"] = bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"start": bson.M{"$lt": query.AvailableBetween.End},
+			"end":   bson.M{"$gt": query.AvailableBetween.Start},
+		}}}
+		if query.AvailableFrom == nil || query.AvailableBetween.Start.Before(*query.AvailableFrom) {
+			filter["availableFrom"] = bson.M{"$lte": query.AvailableBetween.Start}
+		}
+	}
+
+	addFlag(filter, "available", query.Available)
+	addFlag(filter, "amenities.airConditioning", query.Amenities.AirConditioning)
+	addFlag(filter, "amenities.heating", query.Amenities.Heating)
+	addFlag(filter, "amenities.refrigerator", query.Amenities.Refrigerator)
+	addFlag(filter, "amenities.parking", query.Amenities.Parking)
+	addFlag(filter, "rules.petsAllowed", query.Rules.PetsAllowed)
+	addFlag(filter, "rules.partiesAllowed", query.Rules.PartiesAllowed)
+	addFlag(filter, "rules.smokingAllowed", query.Rules.SmokingAllowed)
+	addFlag(filter, "sale.negotiable", query.Sale.Negotiable)
+
+	// A search can combine a radius and an area, both constrain the location
+	var areas bson.A
+	if query.Near != nil {
+		areas = append(areas, bson.M{"location": bson.M{"$geoWithin": bson.M{
+			"$centerSphere": bson.A{query.Near.Point.Coordinates, query.Near.Radius / geo.EarthRadius},
+		}}})
+	}
+	if query.Within != nil {
+		areas = append(areas, bson.M{"location": bson.M{"$geoWithin": bson.M{"$geometry": query.Within}}})
+	}
+	switch len(areas) {
+	case 1:
+		filter["location"] = areas[0].(bson.M)["location"]
+	case 2:
+		filter["$and"] = areas
+	}
+
+	return filter
+}
+
+func addRange(filter bson.M, field string, min, max *int64) {
+	bounds := bson.M{}
+	if min != nil {
+		bounds["$gte"] = *min
+	}
+	if max != nil {
+		bounds["$lte"] = *max
+	}
+	if len(bounds) > 0 {
+		filter[field] = bounds
+	}
+}
+
+func addFlag(filter bson.M, field string, value *bool) {
+	if value != nil {
+		filter[field] = *value
+	}
+}
+
+// and combines filters, skipping the empty ones
+func and(filters ...bson.M) bson.M {
+	var nonEmpty bson.A
+	for _, filter := range filters {
+		if len(filter) > 0 {
+			nonEmpty = append(nonEmpty, filter)
+		}
+	}
+	switch len(nonEmpty) {
+	case 0:
+		return bson.M{}
+	case 1:
+		return nonEmpty[0].(bson.M)
+	default:
+		return bson.M{"$and": nonEmpty}
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FindOwnerRental retrieves a rental of the owner with the given name at the given address, nil if there is none.
+// Imports use it to skip the rentals already listed.
+func (r *rentalRepository) FindOwnerRental(ctx context.Context, owner primitive.ObjectID, name string, address types.Address) (*types.Rental, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"createdBy":            owner,
+		"name":                 name,
+		"address.streetNumber": address.StreetNumber,
+		"address.street":       address.Street,
+		"address.city":         address.City,
+		"address.country":      address.Country,
+		"deletedAt":            nil,
+	}
+
+	var rental types.Rental
+	err := r.collection.FindOne(ctx, filter).Decode(&rental)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Error finding rental of owner: %v", err)
+		return nil, err
+	}
+
+	return &rental, nil
+}
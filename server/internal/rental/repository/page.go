@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"math"
+
+	"server/internal/geo"
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded or does not match the requested sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the decoded form of RentalPage.NextCursor.
+// It remembers the sort key and _id of the last item so the next page starts right after it.
+type pageCursor struct {
+	Sort       types.SortField    `bson:"s"`
+	Descending bool               `bson:"d"`
+	Value      interface{}        `bson:"v"`
+	ID         primitive.ObjectID `bson:"id"`
+}
+
+// decodedCursor mirrors pageCursor but keeps the sort value in its raw BSON form
+type decodedCursor struct {
+	Sort       types.SortField    `bson:"s"`
+	Descending bool               `bson:"d"`
+	Value      bson.RawValue      `bson:"v"`
+	ID         primitive.ObjectID `bson:"id"`
+}
+
+func encodeCursor(cursor pageCursor) (string, error) {
+	raw, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(encoded string, page types.PageRequest) (*decodedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor decodedCursor
+	if err := bson.Unmarshal(raw, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != page.Sort || cursor.Descending != page.Descending {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// unpricedSortValue stands for the TND price of the rentals whose currency has no rate when sorting by price.
+// Mongo only compares values of the same type, a missing price in a cursor would skip the priced rentals,
+// so it is a price out of reach that puts them last in both directions.
+func unpricedSortValue(descending bool) int64 {
+	if descending {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
+// sortValue returns the value of the sort field for the given rental
+func sortValue(rental types.Rental, page types.PageRequest) interface{} {
+	switch page.Sort {
+	case types.SortByPrice:
+		if rental.PriceTND != nil {
+			return *rental.PriceTND
+		}
+		return unpricedSortValue(page.Descending)
+	case types.SortByAreaSize:
+		return rental.AreaSize
+	case types.SortByDistance:
+		if rental.Distance != nil {
+			return *rental.Distance
+		}
+		return 0.0
+	case types.SortByRelevance:
+		if rental.Score != nil {
+			return *rental.Score
+		}
+		return 0.0
+	default:
+		return rental.CreatedAt
+	}
+}
+
+// resumeFilter matches the items that come after the cursor, comparison being $gt or $lt with the sort direction
+func resumeFilter(field string, comparison string, cursor *decodedCursor) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{comparison: cursor.Value}},
+		bson.M{field: cursor.Value, "_id": bson.M{comparison: cursor.ID}},
+	}}
+}
+
+// paginate runs a keyset paginated search over the rentals collection.
+// scope holds conditions that are not part of the user facing query, such as the owner of the rentals.
+// Results are ordered by the sort field with _id as tie breaker, which keeps the order stable across pages.
+// Searches around a point go through $geoNear so that every item carries its distance,
+// except text searches: $text and $geoNear cannot share a pipeline, so their distance is computed afterwards.
+func (r *rentalRepository) paginate(ctx context.Context, query types.RentalQuery, scope bson.M, page types.PageRequest) (*types.RentalPage, error) {
+	field := string(page.Sort)
+	switch page.Sort {
+	case types.SortByPrice:
+		// Prices in different currencies are compared in TND, copied into sortPrice by the $addFields stage below
+		field = "sortPrice"
+	case types.SortByRelevance:
+		// Text scores are copied into score by the $addFields stage below
+		field = "score"
+	}
+	direction, comparison := 1, "$gt"
+	if page.Descending {
+		direction, comparison = -1, "$lt"
+	}
+
+	filter := and(scope, buildRentalFilter(query))
+	result := &types.RentalPage{Items: []types.Rental{}}
+
+	if page.IncludeTotal {
+		total, err := r.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	var pipeline mongo.Pipeline
+	if query.Near != nil && query.Text == "" {
+		// $geoNear applies the radius itself and must be the first stage
+		withoutNear := query
+		withoutNear.Near = nil
+		pipeline = append(pipeline, bson.D{{Key: "$geoNear", Value: bson.M{
+			"near":          query.Near.Point,
+			"key":           "location",
+			"distanceField": "distance",
+			"maxDistance":   query.Near.Radius,
+			"spherical":     true,
+			"query":         and(scope, buildRentalFilter(withoutNear)),
+		}}})
+	} else {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+	if query.Text != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}})
+	}
+	if page.Sort == types.SortByPrice {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{"sortPrice": bson.M{
+			"$ifNull": bson.A{"$priceTND", unpricedSortValue(page.Descending)},
+		}}}})
+	}
+
+	// Resume after the last item of the previous page
+	if page.Cursor != "" {
+		cursor, err := decodeCursor(page.Cursor, page)
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: resumeFilter(field, comparison, cursor)}})
+	}
+
+	// Fetch one extra document to know whether another page exists
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}}},
+		bson.D{{Key: "$limit", Value: page.Limit + 1}},
+	)
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &result.Items); err != nil {
+		return nil, err
+	}
+
+	if query.Near != nil {
+		for i := range result.Items {
+			if result.Items[i].Distance == nil && result.Items[i].Location != nil {
+				distance := geo.Distance(query.Near.Point, *result.Items[i].Location)
+				result.Items[i].Distance = &distance
+			}
+		}
+	}
+
+	if err := trimPage(result, page); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// trimPage drops the extra item fetched past the page limit, whose presence means another page exists,
+// and sets the cursor of that page
+func trimPage(result *types.RentalPage, page types.PageRequest) error {
+	if int64(len(result.Items)) <= page.Limit {
+		return nil
+	}
+
+	result.Items = result.Items[:page.Limit]
+	last := result.Items[len(result.Items)-1]
+	next, err := encodeCursor(pageCursor{
+		Sort:       page.Sort,
+		Descending: page.Descending,
+		Value:      sortValue(last, page),
+		ID:         last.ID,
+	})
+	if err != nil {
+		return err
+	}
+	result.NextCursor = next
+	return nil
+}
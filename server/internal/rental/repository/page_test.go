@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 7, 1, 12, 30, 0, 0, time.UTC)
+	distance, score := 1250.5, 3.75
+	rental := types.Rental{
+		ID:        primitive.NewObjectID(),
+		PriceTND:  price(450),
+		AreaSize:  80,
+		CreatedAt: createdAt,
+		Distance:  &distance,
+		Score:     &score,
+	}
+
+	tests := []struct {
+		sort  types.SortField
+		value interface{}
+	}{
+		{types.SortByPrice, int64(450)},
+		{types.SortByAreaSize, int64(80)},
+		{types.SortByCreatedAt, createdAt},
+		{types.SortByDistance, distance},
+		{types.SortByRelevance, score},
+	}
+
+	for _, test := range tests {
+		for _, descending := range []bool{false, true} {
+			page := types.PageRequest{Sort: test.sort, Descending: descending}
+			encoded, err := encodeCursor(pageCursor{
+				Sort:       page.Sort,
+				Descending: page.Descending,
+				Value:      sortValue(rental, page),
+				ID:         rental.ID,
+			})
+			if err != nil {
+				t.Fatalf("%s descending=%v: encodeCursor: %v", test.sort, descending, err)
+			}
+			cursor, err := decodeCursor(encoded, page)
+			if err != nil {
+				t.Fatalf("%s descending=%v: decodeCursor: %v", test.sort, descending, err)
+			}
+			if cursor.ID != rental.ID {
+				t.Fatalf("%s descending=%v: cursor id = %s, want %s", test.sort, descending, cursor.ID.Hex(), rental.ID.Hex())
+			}
+			if order, comparable := compareValues(t, cursor.Value, test.value); !comparable || order != 0 {
+				t.Fatalf("%s descending=%v: cursor value = %v, want %v", test.sort, descending, cursor.Value, test.value)
+			}
+		}
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	page := types.PageRequest{Sort: types.SortByPrice, Descending: true}
+	valid, err := encodeCursor(pageCursor{Sort: page.Sort, Descending: page.Descending, Value: int64(100), ID: primitive.NewObjectID()})
+	if err != nil {
+		t.Fatalf("encodeCursor: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		page   types.PageRequest
+	}{
+		{"not base64", "not a cursor!", page},
+		{"not bson", base64.RawURLEncoding.EncodeToString([]byte("cursor")), page},
+		{"other sort", valid, types.PageRequest{Sort: types.SortByAreaSize, Descending: true}},
+		{"other direction", valid, types.PageRequest{Sort: types.SortByPrice}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := decodeCursor(test.cursor, test.page); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("decodeCursor error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestSortValue(t *testing.T) {
+	createdAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	rental := types.Rental{AreaSize: 60, CreatedAt: createdAt}
+
+	tests := []struct {
+		name string
+		page types.PageRequest
+		want interface{}
+	}{
+		{"unpriced last ascending", types.PageRequest{Sort: types.SortByPrice}, int64(math.MaxInt64)},
+		{"unpriced last descending", types.PageRequest{Sort: types.SortByPrice, Descending: true}, int64(math.MinInt64)},
+		{"area size", types.PageRequest{Sort: types.SortByAreaSize}, int64(60)},
+		{"creation date", types.PageRequest{Sort: types.SortByCreatedAt, Descending: true}, createdAt},
+		{"no distance", types.PageRequest{Sort: types.SortByDistance}, 0.0},
+		{"no score", types.PageRequest{Sort: types.SortByRelevance, Descending: true}, 0.0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sortValue(rental, test.page); got != test.want {
+				t.Fatalf("sortValue = %v (%T), want %v (%T)", got, got, test.want, test.want)
+			}
+		})
+	}
+}
+
+func TestResumeFilter(t *testing.T) {
+	early := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(24 * time.Hour)
+	ids := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID()}
+
+	tests := []struct {
+		name       string
+		sort       types.SortField
+		descending bool
+		last       types.Rental // last item of the previous page
+		next       types.Rental
+		want       bool
+	}{
+		{"price ascending, higher", types.SortByPrice, false, types.Rental{ID: ids[1], PriceTND: price(100)}, types.Rental{ID: ids[0], PriceTND: price(200)}, true},
+		{"price ascending, lower", types.SortByPrice, false, types.Rental{ID: ids[0], PriceTND: price(200)}, types.Rental{ID: ids[1], PriceTND: price(100)}, false},
+		{"price ascending, unpriced after priced", types.SortByPrice, false, types.Rental{ID: ids[1], PriceTND: price(100)}, types.Rental{ID: ids[0]}, true},
+		{"price ascending, priced after unpriced", types.SortByPrice, false, types.Rental{ID: ids[0]}, types.Rental{ID: ids[1], PriceTND: price(100)}, false},
+		{"price ascending, tie on id", types.SortByPrice, false, types.Rental{ID: ids[0], PriceTND: price(100)}, types.Rental{ID: ids[1], PriceTND: price(100)}, true},
+		{"price descending, lower", types.SortByPrice, true, types.Rental{ID: ids[0], PriceTND: price(200)}, types.Rental{ID: ids[1], PriceTND: price(100)}, true},
+		{"price descending, unpriced after priced", types.SortByPrice, true, types.Rental{ID: ids[0], PriceTND: price(100)}, types.Rental{ID: ids[1]}, true},
+		{"price descending, unpriced tie on id", types.SortByPrice, true, types.Rental{ID: ids[1]}, types.Rental{ID: ids[0]}, true},
+		{"price descending, same item", types.SortByPrice, true, types.Rental{ID: ids[0]}, types.Rental{ID: ids[0]}, false},
+		{"area ascending, larger", types.SortByAreaSize, false, types.Rental{ID: ids[1], AreaSize: 50}, types.Rental{ID: ids[0], AreaSize: 70}, true},
+		{"area descending, larger", types.SortByAreaSize, true, types.Rental{ID: ids[0], AreaSize: 50}, types.Rental{ID: ids[1], AreaSize: 70}, false},
+		{"creation descending, older", types.SortByCreatedAt, true, types.Rental{ID: ids[0], CreatedAt: late}, types.Rental{ID: ids[1], CreatedAt: early}, true},
+		{"creation descending, tie on id", types.SortByCreatedAt, true, types.Rental{ID: ids[0], CreatedAt: early}, types.Rental{ID: ids[1], CreatedAt: early}, false},
+		{"creation ascending, newer", types.SortByCreatedAt, false, types.Rental{ID: ids[0], CreatedAt: early}, types.Rental{ID: ids[1], CreatedAt: late}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := types.PageRequest{Sort: test.sort, Descending: test.descending}
+			encoded, err := encodeCursor(pageCursor{Sort: page.Sort, Descending: page.Descending, Value: sortValue(test.last, page), ID: test.last.ID})
+			if err != nil {
+				t.Fatalf("encodeCursor: %v", err)
+			}
+			cursor, err := decodeCursor(encoded, page)
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			comparison := "$gt"
+			if page.Descending {
+				comparison = "$lt"
+			}
+
+			filter := resumeFilter("value", comparison, cursor)
+			doc := bson.M{"value": sortValue(test.next, page), "_id": test.next.ID}
+			if got := matches(t, filter, doc); got != test.want {
+				t.Fatalf("resumeFilter matched = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPaginatePriceAcrossUnpriced(t *testing.T) {
+	prices := []*int64{price(300), nil, price(100), price(200), nil, price(100), nil, price(250)}
+	rentals := make([]types.Rental, len(prices))
+	for i, p := range prices {
+		rentals[i] = types.Rental{ID: primitive.NewObjectID(), PriceTND: p}
+	}
+
+	for _, descending := range []bool{false, true} {
+		for _, limit := range []int64{1, 2, 3} {
+			page := types.PageRequest{Limit: limit, Sort: types.SortByPrice, Descending: descending}
+			var listed []types.Rental
+			for {
+				result := listPage(t, rentals, page)
+				listed = append(listed, result.Items...)
+				if result.NextCursor == "" {
+					break
+				}
+				page.Cursor = result.NextCursor
+			}
+
+			if len(listed) != len(rentals) {
+				t.Fatalf("descending=%v limit=%d: listed %d rentals, want %d", descending, limit, len(listed), len(rentals))
+			}
+			seen := map[primitive.ObjectID]bool{}
+			for i, rental := range listed {
+				if seen[rental.ID] {
+					t.Fatalf("descending=%v limit=%d: rental %s listed twice", descending, limit, rental.ID.Hex())
+				}
+				seen[rental.ID] = true
+				if i == 0 {
+					continue
+				}
+				previous := listed[i-1]
+				if rental.PriceTND != nil && previous.PriceTND == nil {
+					t.Fatalf("descending=%v limit=%d: priced rental listed after an unpriced one", descending, limit)
+				}
+				if rental.PriceTND != nil && (*rental.PriceTND > *previous.PriceTND) == descending && *rental.PriceTND != *previous.PriceTND {
+					t.Fatalf("descending=%v limit=%d: price %d listed after %d", descending, limit, *rental.PriceTND, *previous.PriceTND)
+				}
+			}
+		}
+	}
+}
+
+func price(value int64) *int64 {
+	return &value
+}
+
+// listPage mirrors the price sorted paginate pipeline over rentals held in memory:
+// the sortPrice $addFields stage, the resume $match, the $sort and the $limit of one extra item
+func listPage(t *testing.T, rentals []types.Rental, page types.PageRequest) *types.RentalPage {
+	t.Helper()
+
+	type document struct {
+		rental    types.Rental
+		sortPrice interface{}
+	}
+	var documents []document
+	for _, rental := range rentals {
+		var sortPrice interface{} = unpricedSortValue(page.Descending)
+		if rental.PriceTND != nil {
+			sortPrice = *rental.PriceTND
+		}
+		documents = append(documents, document{rental, sortPrice})
+	}
+
+	if page.Cursor != "" {
+		cursor, err := decodeCursor(page.Cursor, page)
+		if err != nil {
+			t.Fatalf("decodeCursor: %v", err)
+		}
+		comparison := "$gt"
+		if page.Descending {
+			comparison = "$lt"
+		}
+		filter := resumeFilter("sortPrice", comparison, cursor)
+
+		var after []document
+		for _, doc := range documents {
+			if matches(t, filter, bson.M{"sortPrice": doc.sortPrice, "_id": doc.rental.ID}) {
+				after = append(after, doc)
+			}
+		}
+		documents = after
+	}
+
+	sort.Slice(documents, func(i, j int) bool {
+		order, _ := compareValues(t, documents[i].sortPrice, documents[j].sortPrice)
+		if order == 0 {
+			order = bytes.Compare(documents[i].rental.ID[:], documents[j].rental.ID[:])
+		}
+		if page.Descending {
+			return order > 0
+		}
+		return order < 0
+	})
+	if int64(len(documents)) > page.Limit+1 {
+		documents = documents[:page.Limit+1]
+	}
+
+	result := &types.RentalPage{Items: []types.Rental{}}
+	for _, doc := range documents {
+		result.Items = append(result.Items, doc.rental)
+	}
+	if err := trimPage(result, page); err != nil {
+		t.Fatalf("trimPage: %v", err)
+	}
+	return result
+}
+
+// matches evaluates the $or, equality, $gt and $lt filters built by resumeFilter against a document
+func matches(t *testing.T, filter bson.M, doc bson.M) bool {
+	t.Helper()
+	for key, condition := range filter {
+		if key == "$or" {
+			matched := false
+			for _, branch := range condition.(bson.A) {
+				if matches(t, branch.(bson.M), doc) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+			continue
+		}
+
+		operators, ok := condition.(bson.M)
+		if !ok {
+			operators = bson.M{"$eq": condition}
+		}
+		for operator, value := range operators {
+			order, comparable := compareValues(t, doc[key], value)
+			switch {
+			case !comparable:
+				return false
+			case operator == "$eq" && order != 0, operator == "$gt" && order <= 0, operator == "$lt" && order >= 0:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareValues orders two values the way Mongo query comparisons do: only values of the same type
+// are comparable, numbers of any type being one type. comparable is false otherwise.
+func compareValues(t *testing.T, a, b interface{}) (order int, comparable bool) {
+	t.Helper()
+	x, y := rawValue(t, a), rawValue(t, b)
+
+	if number, ok := numberValue(x); ok {
+		other, ok := numberValue(y)
+		if !ok {
+			return 0, false
+		}
+		// Int64 extremes lose precision as floats, compare them exactly when both are Int64
+		if x.Type == bsontype.Int64 && y.Type == bsontype.Int64 {
+			return compareInt64(x.Int64(), y.Int64()), true
+		}
+		switch {
+		case number < other:
+			return -1, true
+		case number > other:
+			return 1, true
+		}
+		return 0, true
+	}
+	if x.Type != y.Type {
+		return 0, false
+	}
+	switch x.Type {
+	case bsontype.ObjectID:
+		a, b := x.ObjectID(), y.ObjectID()
+		return bytes.Compare(a[:], b[:]), true
+	case bsontype.DateTime:
+		return compareInt64(x.DateTime(), y.DateTime()), true
+	case bsontype.Null:
+		return 0, true
+	}
+	t.Fatalf("cannot compare %s values", x.Type)
+	return 0, false
+}
+
+func numberValue(value bson.RawValue) (float64, bool) {
+	switch value.Type {
+	case bsontype.Int32:
+		return float64(value.Int32()), true
+	case bsontype.Int64:
+		return float64(value.Int64()), true
+	case bsontype.Double:
+		return value.Double(), true
+	}
+	return 0, false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func rawValue(t *testing.T, value interface{}) bson.RawValue {
+	t.Helper()
+	if raw, ok := value.(bson.RawValue); ok {
+		return raw
+	}
+	kind, data, err := bson.MarshalValue(value)
+	if err != nil {
+		t.Fatalf("MarshalValue(%v): %v", value, err)
+	}
+	return bson.RawValue{Type: kind, Value: data}
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RepriceRentals recomputes the normalised price of every rental from the given rates,
+// the value of one unit of each currency in TND. Rentals priced in a currency without rate lose their normalised price.
+// The version is left alone, the listing itself did not change.
+func (r *rentalRepository) RepriceRentals(ctx context.Context, rates map[string]float64) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	branches := bson.A{}
+	for currency, rate := range rates {
+		branches = append(branches, bson.M{
+			"case": bson.M{"$eq": bson.A{"$currency", currency}},
+			"then": bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$price", rate}}, 0}}},
+		})
+	}
+
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"priceTND": bson.M{"$switch": bson.M{"branches": branches, "default": "$$REMOVE"}},
+	}}}}
+	result, err := r.collection.UpdateMany(ctx, bson.M{}, update)
+	if err != nil {
+		log.Printf("Error repricing rentals: %v", err)
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
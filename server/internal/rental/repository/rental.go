@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"server/internal/geo"
 	types "server/internal/rental/types"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,13 +15,52 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrStatusConflict is returned when a rental is no longer in the status a transition starts from
+var ErrStatusConflict = errors.New("rental status has changed")
+
+// ErrVersionConflict is returned when a rental was changed since the version an update is based on
+var ErrVersionConflict = errors.New("rental has been modified")
+
 type RentalRepository interface {
 	AddRental(ctx context.Context, rental types.Rental) error
-	GetAllRentals(ctx context.Context) ([]types.Rental, error)
+	GetAllRentals(ctx context.Context, query types.RentalQuery, page types.PageRequest) (*types.RentalPage, error)
+	GetRentalMarkers(ctx context.Context, query types.RentalQuery, limit int64) ([]types.RentalMarker, error)
+	GetRentalClusters(ctx context.Context, query types.RentalQuery, cellSize float64) ([]types.RentalCluster, error)
+	GetRentalFacets(ctx context.Context, query types.RentalQuery) (*types.RentalFacets, error)
 	GetRentalByID(ctx context.Context, id string) (*types.Rental, error)
-	GetRentalsByUserID(ctx context.Context, id string) ([]types.Rental, error)
-	UpdateRental(ctx context.Context, id string, updatedData types.Rental) error
+	GetDeletedRentalByID(ctx context.Context, id string) (*types.Rental, error)
+	GetRentalsByUserID(ctx context.Context, id string, publishedOnly bool, page types.PageRequest) (*types.RentalPage, error)
+	GetRentalsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]types.Rental, error)
+	GetPublishedRentalsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]types.Rental, error)
+	MatchesQuery(ctx context.Context, id primitive.ObjectID, query types.RentalQuery) (bool, error)
+	UpdateRental(ctx context.Context, id string, updatedData types.Rental, version int64) error
+	UpdateRentalStatus(ctx context.Context, id string, from, to types.Status, moderation *types.Moderation, expiresAt *time.Time) error
+	GetRentalsByStatus(ctx context.Context, status types.Status, page types.PageRequest) (*types.RentalPage, error)
+	GetDraftsByUserID(ctx context.Context, userID primitive.ObjectID, page types.PageRequest) (*types.RentalPage, error)
+	SchedulePublish(ctx context.Context, id string, at *time.Time) error
+	PublishDraft(ctx context.Context, id string) error
+	GetDueDrafts(ctx context.Context, now time.Time) ([]types.Rental, error)
+	GetExpiredRentals(ctx context.Context, now time.Time) ([]types.Rental, error)
+	ExpireRental(ctx context.Context, id string, now time.Time) error
+	GetExpiringRentals(ctx context.Context, userID primitive.ObjectID, before time.Time, page types.PageRequest) (*types.RentalPage, error)
+	BackfillExpiry(ctx context.Context, policy types.ExpiryPolicy, from time.Time) (int64, error)
+	FindOwnerRental(ctx context.Context, owner primitive.ObjectID, name string, address types.Address) (*types.Rental, error)
+	ForEachRental(ctx context.Context, query types.RentalQuery, owner *primitive.ObjectID, fn func(types.Rental) error) error
+	FindDuplicateCandidates(ctx context.Context, rental types.Rental) ([]types.Rental, error)
+	LinkDuplicates(ctx context.Context, id primitive.ObjectID, links []types.DuplicateLink) error
+	UnlinkDuplicates(ctx context.Context, id, otherID primitive.ObjectID) error
+	GetSuspectedDuplicates(ctx context.Context, page types.PageRequest) (*types.RentalPage, error)
+	AddAvailabilityBlock(ctx context.Context, id string, block types.AvailabilityBlock) error
+	BookPeriod(ctx context.Context, id string, block types.AvailabilityBlock) error
+	RemoveAvailabilityBlock(ctx context.Context, id string, blockID primitive.ObjectID) error
+	ReplaceSourceBlocks(ctx context.Context, id string, source string, blocks []types.AvailabilityBlock) error
+	AddCalendarFeed(ctx context.Context, id string, feed types.CalendarFeed) error
+	RecordFeedSync(ctx context.Context, id primitive.ObjectID, url string, at time.Time, syncErr string) error
+	GetRentalsWithFeeds(ctx context.Context) ([]types.Rental, error)
 	DeleteRental(ctx context.Context, id string) error
+	RestoreRental(ctx context.Context, id string) error
+	RepriceRentals(ctx context.Context, rates map[string]float64) (int64, error)
+	PurgeDeletedRentals(ctx context.Context, deletedBefore time.Time) ([]primitive.ObjectID, error)
 }
 
 type rentalRepository struct {
@@ -55,6 +95,8 @@ func (r *rentalRepository) AddRental(ctx context.Context, rental types.Rental) e
 	// Construct FullAddress
 	rental.Address.FullAddress = rental.Address.StreetNumber + " " + rental.Address.Street + ", " +
 		rental.Address.City + ", " + rental.Address.Country
+	setLocation(&rental)
+	setDuplicateKeys(&rental)
 
 	_, err := r.collection.InsertOne(ctx, rental)
 	if err != nil {
@@ -64,33 +106,122 @@ func (r *rentalRepository) AddRental(ctx context.Context, rental types.Rental) e
 	return nil
 }
 
-// GetAllRentals retrieves all rentals from the database
-func (r *rentalRepository) GetAllRentals(ctx context.Context) ([]types.Rental, error) {
-	var rentals []types.Rental
+// GetAllRentals retrieves one page of the rentals matching the given query
+func (r *rentalRepository) GetAllRentals(ctx context.Context, query types.RentalQuery, page types.PageRequest) (*types.RentalPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	cursor, err := r.collection.Find(ctx, bson.D{}, options.Find())
+	result, err := r.paginate(ctx, query, nil, page)
 	if err != nil {
+		log.Printf("Error listing rentals: %v", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetRentalMarkers retrieves the map markers of the rentals matching the given query
+func (r *rentalRepository) GetRentalMarkers(ctx context.Context, query types.RentalQuery, limit int64) ([]types.RentalMarker, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Rentals without a location cannot be drawn
+	filter := and(bson.M{"location": bson.M{"$exists": true}}, buildRentalFilter(query))
+	opts := options.Find().
+		SetProjection(bson.M{"price": 1, "currency": 1, "standing": 1, "location": 1}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Error finding rental markers: %v", err)
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	for cursor.Next(ctx) {
-		var rental types.Rental
-		if err := cursor.Decode(&rental); err != nil {
-			return nil, err
-		}
-		rentals = append(rentals, rental)
+	markers := []types.RentalMarker{}
+	if err = cursor.All(ctx, &markers); err != nil {
+		log.Printf("Error decoding rental markers: %v", err)
+		return nil, err
+	}
+
+	return markers, nil
+}
+
+// GetRentalClusters groups the rentals matching the given query into square grid cells of cellSize degrees.
+// Each cluster is placed at the centroid of its rentals rather than at the center of its cell.
+func (r *rentalRepository) GetRentalClusters(ctx context.Context, query types.RentalQuery, cellSize float64) ([]types.RentalCluster, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cell := func(axis int) bson.M {
+		return bson.M{"$floor": bson.M{"$divide": bson.A{bson.M{"$arrayElemAt": bson.A{"$location.coordinates", axis}}, cellSize}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: and(bson.M{"location": bson.M{"$exists": true}}, buildRentalFilter(query))}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"x": cell(0), "y": cell(1)},
+			"count":    bson.M{"$sum": 1},
+			"lng":      bson.M{"$avg": bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 0}}},
+			"lat":      bson.M{"$avg": bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 1}}},
+			"minPrice": bson.M{"$min": "$priceTND"},
+			// Computed by the server so that large clusters do not gather every price, requires MongoDB 7
+			"medianPrice": bson.M{"$median": bson.M{"input": "$priceTND", "method": "approximate"}},
+			"rentalId":    bson.M{"$first": "$_id"},
+		}}},
 	}
 
-	if err := cursor.Err(); err != nil {
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Error clustering rentals: %v", err)
 		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	return rentals, nil
+	var groups []struct {
+		Count    int64              `bson:"count"`
+		Lng      float64            `bson:"lng"`
+		Lat      float64            `bson:"lat"`
+		MinPrice int64              `bson:"minPrice"`
+		Median   *float64           `bson:"medianPrice"`
+		RentalID primitive.ObjectID `bson:"rentalId"`
+	}
+	if err = cursor.All(ctx, &groups); err != nil {
+		log.Printf("Error decoding rental clusters: %v", err)
+		return nil, err
+	}
+
+	clusters := make([]types.RentalCluster, 0, len(groups))
+	for _, group := range groups {
+		cluster := types.RentalCluster{
+			Count:    group.Count,
+			Centroid: geo.NewPoint(group.Lat, group.Lng),
+			MinPrice: group.MinPrice,
+		}
+		if group.Median != nil {
+			cluster.MedianPrice = *group.Median
+		}
+		if group.Count == 1 {
+			id := group.RentalID
+			cluster.RentalID = &id
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
 }
 
-// GetRentalByID retrieves a rental by its ID
+// GetRentalByID retrieves a rental by its ID, soft deleted rentals are not returned
 func (r *rentalRepository) GetRentalByID(ctx context.Context, id string) (*types.Rental, error) {
+	return r.findOne(ctx, id, bson.M{"deletedAt": nil})
+}
+
+// GetDeletedRentalByID retrieves a soft deleted rental by its ID
+func (r *rentalRepository) GetDeletedRentalByID(ctx context.Context, id string) (*types.Rental, error) {
+	return r.findOne(ctx, id, bson.M{"deletedAt": bson.M{"$ne": nil}})
+}
+
+func (r *rentalRepository) findOne(ctx context.Context, id string, filter bson.M) (*types.Rental, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -99,9 +230,10 @@ func (r *rentalRepository) GetRentalByID(ctx context.Context, id string) (*types
 		log.Printf("Invalid ID format: %v", err)
 		return nil, errors.New("invalid ID format")
 	}
+	filter["_id"] = objectID
 
 	var rental types.Rental
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&rental)
+	err = r.collection.FindOne(ctx, filter).Decode(&rental)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -113,8 +245,9 @@ func (r *rentalRepository) GetRentalByID(ctx context.Context, id string) (*types
 	return &rental, nil
 }
 
-// GetRentalsByUserID retrieves rentals associated with a specific user ID
-func (r *rentalRepository) GetRentalsByUserID(ctx context.Context, userID string) ([]types.Rental, error) {
+// GetRentalsByUserID retrieves one page of the rentals associated with a specific user ID, drafts excepted.
+// publishedOnly leaves out the pending, declined and expired rentals as well.
+func (r *rentalRepository) GetRentalsByUserID(ctx context.Context, userID string, publishedOnly bool, page types.PageRequest) (*types.RentalPage, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -124,16 +257,47 @@ func (r *rentalRepository) GetRentalsByUserID(ctx context.Context, userID string
 		return nil, errors.New("invalid UserID format")
 	}
 
-	filter := bson.M{"createdBy": objectID}
+	filter := bson.M{"createdBy": objectID, "status": bson.M{"$ne": types.Draft}}
+	if publishedOnly {
+		filter["status"] = types.Agreed
+	}
 
-	cursor, err := r.collection.Find(ctx, filter)
+	result, err := r.paginate(ctx, types.RentalQuery{}, filter, page)
 	if err != nil {
 		log.Printf("Error finding rentals by userID: %v", err)
 		return nil, err
 	}
+
+	return result, nil
+}
+
+// GetRentalsByIDs retrieves the rentals with the given IDs. IDs that match no rental, or a soft deleted one, are ignored.
+func (r *rentalRepository) GetRentalsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]types.Rental, error) {
+	return r.findByIDs(ctx, ids, bson.M{"deletedAt": nil})
+}
+
+// GetPublishedRentalsByIDs retrieves the published rentals with the given IDs, the others are ignored
+func (r *rentalRepository) GetPublishedRentalsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]types.Rental, error) {
+	return r.findByIDs(ctx, ids, bson.M{"deletedAt": nil, "status": types.Agreed})
+}
+
+func (r *rentalRepository) findByIDs(ctx context.Context, ids []primitive.ObjectID, filter bson.M) ([]types.Rental, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rentals := []types.Rental{}
+	if len(ids) == 0 {
+		return rentals, nil
+	}
+
+	filter["_id"] = bson.M{"$in": ids}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		log.Printf("Error finding rentals by IDs: %v", err)
+		return nil, err
+	}
 	defer cursor.Close(ctx)
 
-	var rentals []types.Rental
 	if err = cursor.All(ctx, &rentals); err != nil {
 		log.Printf("Error decoding rentals: %v", err)
 		return nil, err
@@ -142,8 +306,23 @@ func (r *rentalRepository) GetRentalsByUserID(ctx context.Context, userID string
 	return rentals, nil
 }
 
-// UpdateRental updates an existing rental by its ID
-func (r *rentalRepository) UpdateRental(ctx context.Context, id string, updatedData types.Rental) error {
+// MatchesQuery reports whether the rental with the given ID matches the query
+func (r *rentalRepository) MatchesQuery(ctx context.Context, id primitive.ObjectID, query types.RentalQuery) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, and(bson.M{"_id": id}, buildRentalFilter(query)), options.Count().SetLimit(1))
+	if err != nil {
+		log.Printf("Error matching rental against query: %v", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// UpdateRental replaces a rental if it is still at the given version, and moves it to the next version.
+// Rentals stored before versioning have no version field and count as version 0.
+func (r *rentalRepository) UpdateRental(ctx context.Context, id string, updatedData types.Rental, version int64) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -157,23 +336,114 @@ func (r *rentalRepository) UpdateRental(ctx context.Context, id string, updatedD
 		updatedData.Address.FullAddress = updatedData.Address.StreetNumber + " " + updatedData.Address.Street + ", " +
 			updatedData.Address.City + ", " + updatedData.Address.Country
 	}
+	setLocation(&updatedData)
+	setDuplicateKeys(&updatedData)
+	updatedData.Distance, updatedData.Score = nil, nil
+	updatedData.Version = version + 1
 
 	update := bson.M{"$set": updatedData}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	// Details are only set for the rental type, drop those of a previous type,
+	// as well as a normalised price that could not be computed for the new currency,
+	// the scheduled publication of an edited draft and the hashes of removed images
+	unset := bson.M{}
+	if updatedData.Shared == nil {
+		unset["shared"] = ""
+	}
+	if updatedData.Sale == nil {
+		unset["sale"] = ""
+	}
+	if updatedData.Independent == nil {
+		unset["independent"] = ""
+	}
+	if updatedData.PriceTND == nil {
+		unset["priceTND"] = ""
+	}
+	if updatedData.PublishAt == nil {
+		unset["publishAt"] = ""
+	}
+	if len(updatedData.ImageHashes) == 0 {
+		unset["imageHashes"] = ""
+		unset["imageBands"] = ""
+	}
+	update["$unset"] = unset
+
+	filter := bson.M{"_id": objectID, "deletedAt": nil, "version": version}
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Error updating rental: %v", err)
 		return err
 	}
 
 	if result.MatchedCount == 0 {
+		// Tell a stale version apart from a missing rental
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID, "deletedAt": nil})
+		if err != nil {
+			log.Printf("Error checking rental existence: %v", err)
+			return err
+		}
+		if count > 0 {
+			return ErrVersionConflict
+		}
 		return errors.New("no rental found with the given ID")
 	}
 
 	return nil
 }
 
-// DeleteRental deletes a rental by its ID
+// UpdateRentalStatus moves a rental from one status to another.
+// The update only applies if the rental is still in the from status, so concurrent moderation cannot race.
+// The moderation decision and the expiry date are only set when given.
+func (r *rentalRepository) UpdateRentalStatus(ctx context.Context, id string, from, to types.Status, moderation *types.Moderation, expiresAt *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	set := bson.M{"status": to, "updatedAt": time.Now()}
+	if moderation != nil {
+		set["moderation"] = moderation
+	}
+	if expiresAt != nil {
+		set["expiresAt"] = *expiresAt
+	}
+
+	filter := bson.M{"_id": objectID, "status": from, "deletedAt": nil}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		log.Printf("Error updating rental status: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStatusConflict
+	}
+
+	return nil
+}
+
+// GetRentalsByStatus retrieves one page of the rentals in the given status
+func (r *rentalRepository) GetRentalsByStatus(ctx context.Context, status types.Status, page types.PageRequest) (*types.RentalPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.paginate(ctx, types.RentalQuery{}, bson.M{"status": status}, page)
+	if err != nil {
+		log.Printf("Error finding rentals by status: %v", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteRental soft deletes a rental by its ID. The document is kept until it is purged.
 func (r *rentalRepository) DeleteRental(ctx context.Context, id string) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -184,15 +454,124 @@ func (r *rentalRepository) DeleteRental(ctx context.Context, id string) error {
 		return errors.New("invalid ID format")
 	}
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	update := bson.M{"$set": bson.M{"deletedAt": time.Now()}, "$inc": bson.M{"version": 1}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID, "deletedAt": nil}, update)
 	if err != nil {
 		log.Printf("Error deleting rental: %v", err)
 		return err
 	}
 
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
 		return errors.New("no rental found with the given ID")
 	}
 
 	return nil
 }
+
+// RestoreRental brings back a soft deleted rental
+func (r *rentalRepository) RestoreRental(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("Invalid ID format: %v", err)
+		return errors.New("invalid ID format")
+	}
+
+	filter := bson.M{"_id": objectID, "deletedAt": bson.M{"$ne": nil}}
+	update := bson.M{"$set": bson.M{"deletedAt": nil, "updatedAt": time.Now()}, "$inc": bson.M{"version": 1}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error restoring rental: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("no deleted rental found with the given ID")
+	}
+
+	return nil
+}
+
+// PurgeDeletedRentals permanently removes the rentals soft deleted before the given time
+// and returns their IDs.
+func (r *rentalRepository) PurgeDeletedRentals(ctx context.Context, deletedBefore time.Time) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"deletedAt": bson.M{"$ne": nil, "$lt": deletedBefore}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		log.Printf("Error finding rentals to purge: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		log.Printf("Error decoding rentals to purge: %v", err)
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	// A rental restored since it was found must survive, check the deletion date again
+	filter["_id"] = bson.M{"$in": ids}
+	if _, err := r.collection.DeleteMany(ctx, filter); err != nil {
+		log.Printf("Error purging rentals: %v", err)
+		return nil, err
+	}
+
+	// Only report the rentals that are gone, so that the images of restored ones are kept
+	cursor, err = r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		log.Printf("Error finding rentals left after purging: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var left []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &left); err != nil {
+		log.Printf("Error decoding rentals left after purging: %v", err)
+		return nil, err
+	}
+	kept := map[primitive.ObjectID]bool{}
+	for _, doc := range left {
+		kept[doc.ID] = true
+	}
+
+	purged := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !kept[id] {
+			purged = append(purged, id)
+		}
+	}
+	return purged, nil
+}
+
+// setLocation keeps the GeoJSON location in sync with the string geometry sent by the forms
+func setLocation(rental *types.Rental) {
+	point, err := rental.Geometry.Point()
+	if err != nil {
+		rental.Location = nil
+		return
+	}
+	rental.Location = &point
+}
+
+// setDuplicateKeys derives the fields duplicate detection looks up from the address and the image hashes
+func setDuplicateKeys(rental *types.Rental) {
+	rental.AddressKey = rental.Address.Key()
+	rental.ImageBands = types.ImageHashBands(rental.ImageHashes)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// unaudited are the fields left out of the diffs: bookkeeping that changes on every write,
+// values derived from other fields, and search results that are never stored.
+var unaudited = map[string]bool{
+	"_id":           true,
+	"updatedAt":     true,
+	"updatedBy":     true,
+	"lastUpdatedBy": true,
+	"version":       true,
+	"location":      true,
+	"priceTND":      true,
+	"imageHashes":   true,
+	"imageBands":    true,
+	"addressKey":    true,
+	"calendarFeeds": true,
+	"distance":      true,
+	"score":         true,
+}
+
+// GetRentalHistory retrieves the audit trail of a rental, oldest entry first
+func (s *rentalService) GetRentalHistory(ctx context.Context, id string) ([]types.AuditEntry, error) {
+	rentalID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+	return s.audit.GetEntriesByRentalID(ctx, rentalID)
+}
+
+// record writes the field level diff between two versions of a rental to the audit trail.
+// before is nil on creation. Failures are logged, they must not undo a change already stored.
+func (s *rentalService) record(ctx context.Context, action types.AuditAction, actor primitive.ObjectID, before, after *types.Rental) {
+	var rentalID primitive.ObjectID
+	switch {
+	case after != nil:
+		rentalID = after.ID
+	case before != nil:
+		rentalID = before.ID
+	}
+
+	changes, err := diffRentals(before, after)
+	if err != nil {
+		log.Printf("Failed to diff rental %s for the audit trail: %v", rentalID.Hex(), err)
+		return
+	}
+
+	entry := types.AuditEntry{
+		RentalID: rentalID,
+		Action:   action,
+		Actor:    actor,
+		Changes:  changes,
+		At:       time.Now(),
+	}
+	if err := s.audit.AddEntry(ctx, entry); err != nil {
+		log.Printf("Failed to record %s of rental %s in the audit trail: %v", action, rentalID.Hex(), err)
+	}
+}
+
+// diffRentals lists the fields whose BSON value differs between two versions of a rental
+func diffRentals(before, after *types.Rental) ([]types.FieldChange, error) {
+	oldFields, err := flattenRental(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flattenRental(after)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string]bool{}
+	for path := range oldFields {
+		paths[path] = true
+	}
+	for path := range newFields {
+		paths[path] = true
+	}
+
+	changes := []types.FieldChange{}
+	for path := range paths {
+		if unaudited[path] {
+			continue
+		}
+		oldValue, newValue := oldFields[path], newFields[path]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, types.FieldChange{Field: path, Old: oldValue, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// flattenRental maps the dotted BSON path of every leaf field of a rental to its value
+func flattenRental(rental *types.Rental) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if rental == nil {
+		return fields, nil
+	}
+
+	raw, err := bson.Marshal(rental)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	flatten("", doc, fields)
+	return fields, nil
+}
+
+func flatten(prefix string, doc bson.M, fields map[string]interface{}) {
+	for key, value := range doc {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch nested := value.(type) {
+		case bson.M:
+			flatten(path, nested, fields)
+		case bson.D:
+			flatten(path, nested.Map(), fields)
+		default:
+			fields[path] = value
+		}
+	}
+}
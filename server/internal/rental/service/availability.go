@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"server/internal/ical"
+	"server/internal/rental/repository"
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxCalendarSize bounds the size of an imported calendar
+	maxCalendarSize = 2 << 20
+	calendarTimeout = 15 * time.Second
+)
+
+var (
+	// ErrBlockNotFound is returned when a rental has no availability block with the given ID
+	ErrBlockNotFound = repository.ErrBlockNotFound
+	// ErrCalendarTooLarge is returned when an imported calendar exceeds maxCalendarSize
+	ErrCalendarTooLarge = fmt.Errorf("%w: larger than %d bytes", ical.ErrInvalidCalendar, maxCalendarSize)
+)
+
+// AddAvailabilityBlock closes a period of the calendar of a rental on behalf of actor
+func (s *rentalService) AddAvailabilityBlock(ctx context.Context, id string, block types.AvailabilityBlock, actor primitive.ObjectID) (*types.AvailabilityBlock, error) {
+	if id == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+
+	block.Start, block.End = types.Day(block.Start), types.Day(block.End)
+	if block.Start.IsZero() || !block.End.After(block.Start) {
+		return nil, errors.New("the end of a block must be after its start")
+	}
+	block.ID = primitive.NewObjectID()
+	block.Kind = types.Blocked
+	block.Source = types.ManualSource
+	block.UID = ""
+
+	previous, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		return nil, ErrRentalNotFound
+	}
+
+	if err := s.repo.AddAvailabilityBlock(ctx, id, block); err != nil {
+		return nil, err
+	}
+
+	s.recordChange(ctx, id, actor, previous)
+	return &block, nil
+}
+
+// RemoveAvailabilityBlock reopens a period of the calendar of a rental on behalf of actor.
+// Blocks held by reservations are released through the reservation instead.
+func (s *rentalService) RemoveAvailabilityBlock(ctx context.Context, id string, blockID string, actor primitive.ObjectID) error {
+	objectID, err := primitive.ObjectIDFromHex(blockID)
+	if err != nil {
+		return errors.New("invalid block ID format")
+	}
+
+	previous, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return ErrRentalNotFound
+	}
+	for _, block := range previous.Availability {
+		if block.ID == objectID && block.Kind == types.Booked {
+			return errors.New("booked periods cannot be removed from the calendar")
+		}
+	}
+
+	if err := s.repo.RemoveAvailabilityBlock(ctx, id, objectID); err != nil {
+		return err
+	}
+
+	s.recordChange(ctx, id, actor, previous)
+	return nil
+}
+
+// ImportCalendar replaces the blocks previously imported from source with the events of an iCalendar document,
+// and returns how many blocks were imported. Cancelled events are skipped.
+// A calendar too large to be read whole is rejected, importing part of it would drop the bookings it leaves out.
+// Nothing is written when the blocks did not change, which keeps periodic syncs out of the version and the audit trail.
+func (s *rentalService) ImportCalendar(ctx context.Context, id string, source string, calendar io.Reader, actor primitive.ObjectID) (int, error) {
+	if source == "" || source == types.ManualSource || source == types.ReservationSource {
+		return 0, errors.New("invalid calendar source")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(calendar, maxCalendarSize+1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	if len(data) > maxCalendarSize {
+		return 0, ErrCalendarTooLarge
+	}
+	events, err := ical.Parse(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	previous, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if previous == nil {
+		return 0, ErrRentalNotFound
+	}
+
+	// Events imported before keep the ID of their block
+	current := map[string]types.AvailabilityBlock{}
+	for _, block := range previous.Availability {
+		if block.Source == source {
+			current[block.UID] = block
+		}
+	}
+
+	blocks := []types.AvailabilityBlock{}
+	kept := map[primitive.ObjectID]bool{}
+	unchanged := true
+	for _, event := range events {
+		if event.Status == "CANCELLED" {
+			continue
+		}
+		block := blockFromEvent(event, source)
+		// Recurring events share their UID, only the first occurrence keeps the ID
+		if existing, ok := current[block.UID]; ok && block.UID != "" && !kept[existing.ID] {
+			block.ID = existing.ID
+			kept[existing.ID] = true
+			unchanged = unchanged && existing.Start.Equal(block.Start) && existing.End.Equal(block.End)
+		} else {
+			unchanged = false
+		}
+		blocks = append(blocks, block)
+	}
+	if unchanged && len(blocks) == len(current) {
+		return len(blocks), nil
+	}
+
+	if err := s.repo.ReplaceSourceBlocks(ctx, id, source, blocks); err != nil {
+		return 0, err
+	}
+
+	s.recordChange(ctx, id, actor, previous)
+	return len(blocks), nil
+}
+
+// ImportCalendarURL downloads an iCalendar feed and imports it, the URL being the source of the blocks.
+// The feed is then synced every types.FeedSyncInterval. Importing the same URL again refreshes its blocks.
+func (s *rentalService) ImportCalendarURL(ctx context.Context, id string, feedURL string, actor primitive.ObjectID) (int, error) {
+	imported, err := s.syncFeed(ctx, id, feedURL, actor)
+	if err != nil {
+		return 0, err
+	}
+
+	s.registerFeed(ctx, id, feedURL, actor)
+	return imported, nil
+}
+
+// GetRentalCalendar exports the calendar of a rental as iCalendar events, one per block
+func (s *rentalService) GetRentalCalendar(rental types.Rental) *ical.Calendar {
+	calendar := &ical.Calendar{
+		ProdID: "-//Rentals//Availability//EN",
+		Name:   rental.Name,
+	}
+	for _, block := range rental.Availability {
+		summary := "Not available"
+		if block.Kind == types.Booked {
+			summary = "Booked"
+		}
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:     block.ID.Hex() + "@" + rental.ID.Hex(),
+			Summary: summary,
+			Start:   block.Start,
+			End:     block.End,
+			AllDay:  true,
+			Stamp:   rental.UpdatedAt,
+		})
+	}
+	return calendar
+}
+
+// blockFromEvent turns an event into the whole days it covers
+func blockFromEvent(event ical.Event, source string) types.AvailabilityBlock {
+	start, end := types.Day(event.Start), types.Day(event.End)
+	// A timed event ending during a day still takes that day
+	if !event.AllDay && event.End.After(end) {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+
+	return types.AvailabilityBlock{
+		ID:     primitive.NewObjectID(),
+		Kind:   types.Blocked,
+		Start:  start,
+		End:    end,
+		Source: source,
+		UID:    event.UID,
+	}
+}
+
+// recordChange records in the audit trail how a rental changed since previous
+func (s *rentalService) recordChange(ctx context.Context, id string, actor primitive.ObjectID, previous *types.Rental) {
+	updated, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil || updated == nil {
+		log.Printf("Failed to load rental %s for the audit trail: %v", id, err)
+		return
+	}
+	s.record(ctx, types.AuditUpdate, actor, previous, updated)
+}
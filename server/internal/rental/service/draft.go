@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotDraft is returned when a draft action targets a rental that was already published
+var ErrNotDraft = errors.New("rental is not a draft")
+
+// SaveDraft stores an incomplete rental that only its owner sees until it is published.
+// Unlike AddRental the mandatory fields are not checked, they are when the draft is published.
+func (s *rentalService) SaveDraft(ctx context.Context, rental types.Rental) error {
+	rental.Status = types.Draft
+	rental.Moderation = nil
+	rental.PublishAt = nil
+	rental.ExpiresAt = nil
+
+	if rental.Currency == "" {
+		rental.Currency = "TND"
+	}
+	if rental.Standing == "" {
+		rental.Standing = types.Standard
+	}
+	if rental.ID.IsZero() {
+		rental.ID = primitive.NewObjectID()
+	}
+	s.setPriceTND(ctx, &rental)
+	rental.ImageHashes = hashImages(rental.Images)
+	rental.Duplicates = nil
+
+	if err := s.repo.AddRental(ctx, rental); err != nil {
+		return err
+	}
+
+	s.record(ctx, types.AuditCreate, rental.CreatedBy, nil, &rental)
+	return nil
+}
+
+// UpdateDraft stores a new version of a draft on behalf of actor, incomplete fields included.
+// Saving a draft cancels its scheduled publication.
+func (s *rentalService) UpdateDraft(ctx context.Context, id string, draft types.Rental, version int64, actor primitive.ObjectID) error {
+	if id == "" {
+		return errors.New("id cannot be empty")
+	}
+
+	previous, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return ErrRentalNotFound
+	}
+	if previous.Status != types.Draft {
+		return ErrNotDraft
+	}
+
+	if draft.Currency == "" {
+		draft.Currency = "TND"
+	}
+	if draft.Standing == "" {
+		draft.Standing = types.Standard
+	}
+	draft.Address.FullAddress = ""
+	draft.UpdatedAt = time.Now()
+	draft.UpdatedBy = actor
+	draft.LastUpdatedBy = actor
+
+	return s.update(ctx, id, draft, version, actor)
+}
+
+// GetDrafts retrieves one page of the drafts of a user
+func (s *rentalService) GetDrafts(ctx context.Context, userID primitive.ObjectID, page types.PageRequest) (*types.RentalPage, error) {
+	return s.repo.GetDraftsByUserID(ctx, userID, page)
+}
+
+// PublishRental submits a draft for moderation on behalf of actor once its mandatory fields are filled in.
+// When at is in the future the draft is only scheduled, PublishDueDrafts submits it when the time comes.
+func (s *rentalService) PublishRental(ctx context.Context, id string, at *time.Time, actor primitive.ObjectID) error {
+	if id == "" {
+		return errors.New("id cannot be empty")
+	}
+
+	rental, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rental == nil {
+		return ErrRentalNotFound
+	}
+	if rental.Status != types.Draft {
+		return ErrNotDraft
+	}
+	if err := checkMandatoryFields(*rental); err != nil {
+		return err
+	}
+
+	if at != nil && at.After(time.Now()) {
+		if err := s.repo.SchedulePublish(ctx, id, at); err != nil {
+			return err
+		}
+		previous := *rental
+		rental.PublishAt = at
+		s.record(ctx, types.AuditUpdate, actor, &previous, rental)
+		return nil
+	}
+
+	return s.publish(ctx, *rental, actor)
+}
+
+// PublishDueDrafts submits the drafts whose scheduled publication is due, on behalf of their owner.
+// It returns how many drafts were published and is meant to run periodically.
+func (s *rentalService) PublishDueDrafts(ctx context.Context) (int, error) {
+	drafts, err := s.repo.GetDueDrafts(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, draft := range drafts {
+		// Saving a draft cancels its schedule, check again in case it was changed outside the API
+		if err := checkMandatoryFields(draft); err != nil {
+			log.Printf("Skipping incomplete draft %s: %v", draft.ID.Hex(), err)
+			continue
+		}
+		if err := s.publish(ctx, draft, draft.CreatedBy); err != nil {
+			// Edited or published in the meantime
+			log.Printf("Failed to publish draft %s: %v", draft.ID.Hex(), err)
+			continue
+		}
+		published++
+	}
+	return published, nil
+}
+
+// publish moves a draft to the moderation queue and records the change in the audit trail
+func (s *rentalService) publish(ctx context.Context, draft types.Rental, actor primitive.ObjectID) error {
+	if err := s.repo.PublishDraft(ctx, draft.ID.Hex()); err != nil {
+		return err
+	}
+
+	previous := draft
+	draft.Status = types.Pending
+	draft.PublishAt = nil
+	s.record(ctx, types.AuditStatusChange, actor, &previous, &draft)
+	s.checkDuplicates(ctx, draft)
+	return nil
+}
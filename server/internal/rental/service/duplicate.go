@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"server/internal/geo"
+	"server/internal/rental/repository"
+	types "server/internal/rental/types"
+	"server/internal/rental/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrSuspectedDuplicate is returned when approving a rental still linked to suspected duplicates
+	ErrSuspectedDuplicate = errors.New("rental is suspected to duplicate another listing")
+	// ErrNotLinked is returned when dismissing a link that does not exist
+	ErrNotLinked = repository.ErrNotLinked
+)
+
+// DismissDuplicate removes the link between two rentals an admin found to be different flats
+func (s *rentalService) DismissDuplicate(ctx context.Context, id, otherID string, adminID primitive.ObjectID) error {
+	rental, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rental == nil {
+		return ErrRentalNotFound
+	}
+	other, err := primitive.ObjectIDFromHex(otherID)
+	if err != nil {
+		return ErrNotLinked
+	}
+
+	if err := s.repo.UnlinkDuplicates(ctx, rental.ID, other); err != nil {
+		return err
+	}
+
+	previous := *rental
+	rental.Duplicates = nil
+	for _, link := range previous.Duplicates {
+		if link.RentalID != other {
+			rental.Duplicates = append(rental.Duplicates, link)
+		}
+	}
+	s.record(ctx, types.AuditUpdate, adminID, &previous, rental)
+	return nil
+}
+
+// GetSuspectedDuplicates retrieves the pending rentals linked to suspected duplicates, oldest rentals first
+func (s *rentalService) GetSuspectedDuplicates(ctx context.Context, page types.PageRequest) (*types.RentalPage, error) {
+	page.Sort, page.Descending = types.SortByCreatedAt, false
+	return s.repo.GetSuspectedDuplicates(ctx, page)
+}
+
+// checkDuplicates links a rental entering moderation to the listings that look like the same flat.
+// Pictures in common are enough, otherwise the same bedrooms and price must come with the same address or location.
+// Detection never fails the listing, errors are only logged.
+func (s *rentalService) checkDuplicates(ctx context.Context, rental types.Rental) {
+	candidates, err := s.repo.FindDuplicateCandidates(ctx, rental)
+	if err != nil {
+		log.Printf("Error finding duplicates of rental %s: %v", rental.ID.Hex(), err)
+		return
+	}
+
+	now := time.Now()
+	var links []types.DuplicateLink
+	for _, candidate := range candidates {
+		if reasons := duplicateReasons(rental, candidate); len(reasons) > 0 {
+			links = append(links, types.DuplicateLink{RentalID: candidate.ID, Reasons: reasons, DetectedAt: now})
+		}
+	}
+	if len(links) == 0 {
+		return
+	}
+
+	if err := s.repo.LinkDuplicates(ctx, rental.ID, links); err != nil {
+		log.Printf("Error linking duplicates of rental %s: %v", rental.ID.Hex(), err)
+		return
+	}
+	log.Printf("Rental %s flagged as a suspected duplicate of %d listings", rental.ID.Hex(), len(links))
+}
+
+// duplicateReasons tells why two rentals look like the same flat, nothing when they do not
+func duplicateReasons(rental, other types.Rental) []types.DuplicateReason {
+	var reasons []types.DuplicateReason
+	if sameAddress(rental.Address, other.Address) {
+		reasons = append(reasons, types.SameAddress)
+	}
+	if sameLocation(rental.Geometry, other.Geometry) {
+		reasons = append(reasons, types.SameLocation)
+	}
+	if len(reasons) > 0 && sameListing(rental, other) {
+		reasons = append(reasons, types.SameListing)
+	} else {
+		reasons = nil
+	}
+	if sameImages(rental.ImageHashes, other.ImageHashes) {
+		reasons = append(reasons, types.SameImages)
+	}
+	return reasons
+}
+
+func sameAddress(a, b types.Address) bool {
+	key := a.Key()
+	return key != "" && key == b.Key()
+}
+
+func sameLocation(a, b types.Geometry) bool {
+	p, err := a.Point()
+	if err != nil {
+		return false
+	}
+	q, err := b.Point()
+	if err != nil {
+		return false
+	}
+	return geo.Distance(p, q) <= types.DuplicateRadius
+}
+
+// sameListing compares bedrooms and prices, in TND when both could be converted
+func sameListing(a, b types.Rental) bool {
+	if a.Bedrooms != b.Bedrooms {
+		return false
+	}
+
+	priceA, priceB := float64(a.Price), float64(b.Price)
+	if a.PriceTND != nil && b.PriceTND != nil {
+		priceA, priceB = float64(*a.PriceTND), float64(*b.PriceTND)
+	} else if a.Currency != b.Currency {
+		return false
+	}
+	return math.Abs(priceA-priceB) <= types.DuplicatePriceMargin*math.Max(priceA, priceB)
+}
+
+func sameImages(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if distance := utils.HashDistance(x, y); distance >= 0 && distance <= types.MaxImageHashDistance {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hashImages computes the hashes of the uploaded images of a rental.
+// Remote images are not downloaded, and images that cannot be read are skipped.
+func hashImages(images []string) []string {
+	var hashes []string
+	for _, image := range images {
+		if strings.HasPrefix(image, "http://") || strings.HasPrefix(image, "https://") {
+			continue
+		}
+		hash, err := utils.HashImageFile(image)
+		if err != nil {
+			log.Printf("Error hashing image %s: %v", image, err)
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// linkedDuplicates returns the IDs of the live rentals a rental is suspected to duplicate
+func (s *rentalService) linkedDuplicates(ctx context.Context, rental types.Rental) ([]primitive.ObjectID, error) {
+	if len(rental.Duplicates) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(rental.Duplicates))
+	for i, link := range rental.Duplicates {
+		ids[i] = link.RentalID
+	}
+	live, err := s.repo.GetRentalsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	ids = ids[:0]
+	for _, other := range live {
+		ids = append(ids, other.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RenewRental gives a published or expired rental a full lifetime from now on behalf of actor.
+// An expired rental gets back in search without going through moderation again.
+func (s *rentalService) RenewRental(ctx context.Context, id string, actor primitive.ObjectID) (*types.Rental, error) {
+	if id == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+
+	rental, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rental == nil {
+		return nil, ErrRentalNotFound
+	}
+	if rental.Status != types.Agreed && rental.Status != types.Expired {
+		return nil, ErrInvalidTransition
+	}
+
+	expiresAt := s.expiry.ExpiresAt(rental.Type, time.Now())
+	if err := s.repo.UpdateRentalStatus(ctx, id, rental.Status, types.Agreed, nil, &expiresAt); err != nil {
+		return nil, err
+	}
+
+	previous := *rental
+	rental.Status = types.Agreed
+	rental.ExpiresAt = &expiresAt
+	rental.Version++
+	if previous.Status == types.Expired {
+		s.record(ctx, types.AuditStatusChange, actor, &previous, rental)
+		s.notifyPublished(ctx, rental.ID)
+	} else {
+		s.record(ctx, types.AuditUpdate, actor, &previous, rental)
+	}
+	return rental, nil
+}
+
+// ExpireRentals takes the rentals whose lifetime is over out of search and returns how many expired.
+// Rentals published before listings expired are given an expiry date first. It is meant to run periodically.
+func (s *rentalService) ExpireRentals(ctx context.Context) (int, error) {
+	now := time.Now()
+	if count, err := s.repo.BackfillExpiry(ctx, s.expiry, now); err != nil {
+		return 0, err
+	} else if count > 0 {
+		log.Printf("Set the expiry date of %d published rentals", count)
+	}
+
+	rentals, err := s.repo.GetExpiredRentals(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, rental := range rentals {
+		if err := s.repo.ExpireRental(ctx, rental.ID.Hex(), now); err != nil {
+			// Renewed or taken down in the meantime
+			log.Printf("Failed to expire rental %s: %v", rental.ID.Hex(), err)
+			continue
+		}
+
+		// Expiry is not done on behalf of anyone, the actor is left empty
+		previous := rental
+		rental.Status = types.Expired
+		s.record(ctx, types.AuditStatusChange, primitive.NilObjectID, &previous, &rental)
+		expired++
+	}
+	return expired, nil
+}
+
+// GetExpiringRentals retrieves one page of the published rentals of a user that expire within the warning period
+func (s *rentalService) GetExpiringRentals(ctx context.Context, userID primitive.ObjectID, page types.PageRequest) (*types.RentalPage, error) {
+	return s.repo.GetExpiringRentals(ctx, userID, time.Now().Add(s.expiry.Warning), page)
+}
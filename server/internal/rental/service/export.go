@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportRentals calls fn with every rental matching the query, restricted to those of owner unless it is nil.
+// The rentals are streamed from the database, fn is expected to write them out as they come.
+// Unlike searches no status is forced, the query lists the statuses wanted if any.
+func (s *rentalService) ExportRentals(ctx context.Context, query types.RentalQuery, owner *primitive.ObjectID, fn func(types.Rental) error) error {
+	if err := s.normalisePriceRange(ctx, &query); err != nil {
+		return err
+	}
+	return s.repo.ForEachRental(ctx, query, owner, fn)
+}
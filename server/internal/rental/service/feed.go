@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxFeedRedirects bounds the redirects followed when downloading a calendar feed
+const maxFeedRedirects = 5
+
+// ErrForbiddenAddress is returned when a calendar feed resolves to an address of the server network
+var ErrForbiddenAddress = errors.New("the calendar URL must point to a public address")
+
+// reservedPrefixes are the special purpose ranges that IsPrivate, IsLoopback and the like do not cover
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // This network
+	netip.MustParsePrefix("100.64.0.0/10"),   // Carrier grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved, broadcast included
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, reaches IPv4 addresses
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation
+}
+
+// feedClient downloads calendar feeds given by landlords. The addresses are checked once resolved,
+// on every connection, so that neither the feed nor its redirects can reach the server network.
+var feedClient = &http.Client{
+	Timeout: calendarTimeout,
+	Transport: &http.Transport{
+		Proxy: nil, // A proxy would resolve the host itself, out of reach of the check
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				return checkFeedAddress(address)
+			},
+		}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       time.Minute,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxFeedRedirects {
+			return errors.New("too many redirects")
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to a %s URL", req.URL.Scheme)
+		}
+		return nil
+	},
+}
+
+// checkFeedAddress rejects the loopback, private, link local and reserved addresses
+func checkFeedAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return ErrForbiddenAddress
+	}
+	ip = ip.Unmap()
+
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return ErrForbiddenAddress
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(ip) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// feedRequestURL checks a feed URL given by a landlord and returns the URL to download, webcal being served over https
+func feedRequestURL(feedURL string) (string, error) {
+	parsed, err := url.Parse(feedURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https" && parsed.Scheme != "webcal") || parsed.Host == "" {
+		return "", errors.New("the calendar URL must be an http, https or webcal URL")
+	}
+	if parsed.Scheme == "webcal" {
+		parsed.Scheme = "https"
+	}
+	return parsed.String(), nil
+}
+
+// syncFeed downloads a feed and imports it, the URL being the source of the blocks
+func (s *rentalService) syncFeed(ctx context.Context, id string, feedURL string, actor primitive.ObjectID) (int, error) {
+	requestURL, err := feedRequestURL(feedURL)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := feedClient.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) {
+			return 0, ErrForbiddenAddress
+		}
+		return 0, fmt.Errorf("failed to download calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download calendar: status %d", resp.StatusCode)
+	}
+
+	return s.ImportCalendar(ctx, id, feedURL, resp.Body, actor)
+}
+
+// SyncCalendarFeeds imports again every calendar feed registered on a rental, so that periods booked on other sites
+// cannot be booked here, and returns how many feeds were synced. A failing feed keeps its previous blocks.
+func (s *rentalService) SyncCalendarFeeds(ctx context.Context) (int, error) {
+	rentals, err := s.repo.GetRentalsWithFeeds(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	synced := 0
+	for _, rental := range rentals {
+		for _, feed := range rental.CalendarFeeds {
+			if ctx.Err() != nil {
+				return synced, ctx.Err()
+			}
+
+			syncErr := ""
+			if _, err := s.syncFeed(ctx, rental.ID.Hex(), feed.URL, primitive.NilObjectID); err != nil {
+				log.Printf("Failed to sync a calendar feed of rental %s: %v", rental.ID.Hex(), err)
+				syncErr = err.Error()
+			} else {
+				synced++
+			}
+			if err := s.repo.RecordFeedSync(ctx, rental.ID, feed.URL, time.Now(), syncErr); err != nil {
+				log.Printf("Failed to record the sync of a calendar feed of rental %s: %v", rental.ID.Hex(), err)
+			}
+		}
+	}
+	return synced, nil
+}
+
+// registerFeed keeps a feed imported by hand in sync from then on
+func (s *rentalService) registerFeed(ctx context.Context, id string, feedURL string, actor primitive.ObjectID) {
+	now := time.Now()
+	feed := types.CalendarFeed{URL: feedURL, ImportedBy: actor, SyncedAt: &now}
+	if err := s.repo.AddCalendarFeed(ctx, id, feed); err != nil {
+		log.Printf("Failed to register the calendar feed of rental %s: %v", id, err)
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	types "server/internal/rental/types"
+	"server/internal/rental/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidImport is returned when an import file cannot be read at all
+var ErrInvalidImport = utils.ErrInvalidImport
+
+// importValidator checks every imported row against the rental struct tags
+var importValidator = utils.NewValidator()
+
+// ImportRentals creates the rentals of a CSV or JSON Lines file on behalf of owner, see utils.ParseImport for the formats.
+// Each row is validated like a rental created through the API. Rows listed twice, in the file or by the owner already, are skipped.
+// In a dry run the rows are only validated, nothing is written.
+func (s *rentalService) ImportRentals(ctx context.Context, src io.Reader, format string, owner primitive.ObjectID, dryRun bool) (*types.ImportReport, error) {
+	rows, err := utils.ParseImport(src, format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.ImportReport{DryRun: dryRun, Rows: make([]types.ImportRowResult, 0, len(rows))}
+	seen := map[string]int{}
+	for _, row := range rows {
+		report.Add(s.importRow(ctx, row, owner, dryRun, seen))
+	}
+	return report, nil
+}
+
+// importRow creates the rental of one row, seen holds the line of the rows already imported by rental key
+func (s *rentalService) importRow(ctx context.Context, row types.ImportRow, owner primitive.ObjectID, dryRun bool, seen map[string]int) types.ImportRowResult {
+	result := types.ImportRowResult{Line: row.Line, Name: row.Rental.Name, Outcome: types.ImportFailed}
+	if row.Err != nil {
+		result.Error = row.Err.Error()
+		return result
+	}
+
+	// Fields managed by the server, as for rentals created through the API
+	rental := row.Rental
+	now := time.Now()
+	rental.ID = primitive.NewObjectID()
+	rental.Status = types.Pending
+	rental.Moderation = nil
+	rental.CreatedBy = owner
+	rental.UpdatedBy = owner
+	rental.LastUpdatedBy = owner
+	rental.CreatedAt = now
+	rental.UpdatedAt = now
+	rental.DeletedAt = nil
+	rental.Version = 0
+	rental.Availability = nil
+	rental.Location, rental.Distance, rental.Score = nil, nil, nil
+	rental.PriceTND, rental.DisplayPrice = nil, nil
+	rental.PublishAt, rental.ExpiresAt = nil, nil
+	rental.Address.FullAddress = ""
+	if rental.Currency == "" {
+		rental.Currency = "TND"
+	}
+	if rental.Standing == "" {
+		rental.Standing = types.Standard
+	}
+	if rental.Shared != nil && rental.Shared.GenderPreference == "" {
+		rental.Shared.GenderPreference = types.AnyGender
+	}
+
+	if err := importValidator.Struct(rental); err != nil {
+		result.Error = "validation failed"
+		result.Details, _ = utils.ValidationDetails(err)
+		return result
+	}
+	if err := checkMandatoryFields(rental); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	// Imported images are served from where the agency hosts them, or were uploaded here and exported since
+	uploaded := map[int]string{}
+	for i, image := range rental.Images {
+		if path, ok := utils.ImagePathFromURL(image); ok {
+			uploaded[i] = path
+			continue
+		}
+		if !strings.HasPrefix(image, "https://") {
+			result.Error = fmt.Sprintf("image %q is neither an https URL nor an image of this server", image)
+			return result
+		}
+	}
+
+	key := strings.ToLower(strings.Join([]string{rental.Name, rental.Address.StreetNumber, rental.Address.Street, rental.Address.City, rental.Address.Country}, "\x00"))
+	if line, ok := seen[key]; ok {
+		result.Outcome = types.ImportSkipped
+		result.Error = fmt.Sprintf("same rental as line %d", line)
+		return result
+	}
+	seen[key] = row.Line
+
+	existing, err := s.repo.FindOwnerRental(ctx, owner, rental.Name, rental.Address)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if existing != nil {
+		result.Outcome = types.ImportSkipped
+		result.ID = existing.ID.Hex()
+		result.Error = "already listed"
+		return result
+	}
+
+	if !dryRun {
+		rentalFolder := filepath.Join(utils.GetBasePath(), rental.ID.Hex(), "images")
+		if err := s.copyUploadedImages(&rental, uploaded, rentalFolder); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if err := s.AddRental(ctx, rental); err != nil {
+			if len(uploaded) > 0 {
+				os.RemoveAll(filepath.Dir(rentalFolder))
+			}
+			result.Error = err.Error()
+			return result
+		}
+		result.ID = rental.ID.Hex()
+	}
+	result.Outcome = types.ImportCreated
+	return result
+}
+
+// copyUploadedImages gives an imported rental its own copy of the images uploaded to this server,
+// uploaded holding their stored paths by position in the rental images
+func (s *rentalService) copyUploadedImages(rental *types.Rental, uploaded map[int]string, rentalFolder string) error {
+	if len(uploaded) == 0 {
+		return nil
+	}
+
+	positions := make([]int, 0, len(uploaded))
+	paths := make([]string, 0, len(uploaded))
+	for i := range rental.Images {
+		if path, ok := uploaded[i]; ok {
+			positions = append(positions, i)
+			paths = append(paths, path)
+		}
+	}
+
+	copies, err := utils.CopyImages(paths, rentalFolder)
+	if err != nil {
+		os.RemoveAll(filepath.Dir(rentalFolder))
+		return err
+	}
+
+	images := append([]string(nil), rental.Images...)
+	for i, position := range positions {
+		images[position] = copies[i]
+	}
+	rental.Images = images
+	return nil
+}
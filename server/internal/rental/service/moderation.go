@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"server/internal/rental/repository"
+	types "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrRentalNotFound is returned when no rental has the given ID
+	ErrRentalNotFound = errors.New("no rental found with the given ID")
+	// ErrInvalidTransition is returned when the moderation decision is not allowed from the current status
+	ErrInvalidTransition = errors.New("status transition not allowed")
+	// ErrStatusConflict is returned when the rental status changed while the decision was being applied
+	ErrStatusConflict = repository.ErrStatusConflict
+	// ErrVersionConflict is returned when an update is based on an outdated version of the rental
+	ErrVersionConflict = repository.ErrVersionConflict
+	// ErrReasonRequired is returned when declining a rental without telling the landlord why
+	ErrReasonRequired = errors.New("a reason is required to decline a rental")
+)
+
+// ApproveRental publishes a rental. Only pending rentals can be approved,
+// once the links to suspected duplicates that are still listed have been dismissed.
+func (s *rentalService) ApproveRental(ctx context.Context, id string, adminID primitive.ObjectID) error {
+	current, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		duplicates, err := s.linkedDuplicates(ctx, *current)
+		if err != nil {
+			return err
+		}
+		if len(duplicates) > 0 {
+			return ErrSuspectedDuplicate
+		}
+	}
+
+	rental, err := s.transition(ctx, id, types.Agreed, &types.Moderation{
+		ModeratedBy: adminID,
+		ModeratedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPublished(ctx, rental.ID)
+	return nil
+}
+
+// DeclineRental declines a pending rental or takes down an approved one.
+// The reason is stored on the rental so that the landlord can read it.
+func (s *rentalService) DeclineRental(ctx context.Context, id string, adminID primitive.ObjectID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrReasonRequired
+	}
+
+	_, err := s.transition(ctx, id, types.Declined, &types.Moderation{
+		Reason:      reason,
+		ModeratedBy: adminID,
+		ModeratedAt: time.Now(),
+	})
+	return err
+}
+
+// GetPendingRentals retrieves the moderation queue, oldest rentals first
+func (s *rentalService) GetPendingRentals(ctx context.Context, page types.PageRequest) (*types.RentalPage, error) {
+	page.Sort, page.Descending = types.SortByCreatedAt, false
+	return s.repo.GetRentalsByStatus(ctx, types.Pending, page)
+}
+
+// transition moves a rental to the next status if its current status allows it.
+// A rental going live gets a full lifetime before it expires.
+func (s *rentalService) transition(ctx context.Context, id string, next types.Status, moderation *types.Moderation) (*types.Rental, error) {
+	if id == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+
+	rental, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rental == nil {
+		return nil, ErrRentalNotFound
+	}
+	if !rental.Status.CanTransitionTo(next) {
+		return nil, ErrInvalidTransition
+	}
+
+	var expiresAt *time.Time
+	if next == types.Agreed {
+		at := s.expiry.ExpiresAt(rental.Type, time.Now())
+		expiresAt = &at
+	}
+
+	if err := s.repo.UpdateRentalStatus(ctx, id, rental.Status, next, moderation, expiresAt); err != nil {
+		return nil, err
+	}
+
+	previous := *rental
+	rental.Status = next
+	rental.Moderation = moderation
+	if expiresAt != nil {
+		rental.ExpiresAt = expiresAt
+	}
+	s.record(ctx, types.AuditStatusChange, moderation.ModeratedBy, &previous, rental)
+	return rental, nil
+}
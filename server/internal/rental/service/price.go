@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	currencyTypes "server/internal/currency/types"
+	types "server/internal/rental/types"
+	"server/internal/rental/utils"
+)
+
+// ErrPriceConversion is returned when prices cannot be converted from or to the requested currency
+var ErrPriceConversion = errors.New("prices cannot be converted to this currency")
+
+// PriceConverter converts prices between currencies, it is implemented by the currency service
+type PriceConverter interface {
+	ToBase(ctx context.Context, amount int64, currency string) (int64, error)
+	Convert(ctx context.Context, amount int64, from, to string) (int64, float64, error)
+}
+
+// ConvertPrices sets the display price of the rentals in the given currency.
+// Nothing is set when no currency is given, or for the rentals whose currency has no rate.
+func (s *rentalService) ConvertPrices(ctx context.Context, currency string, rentals []types.Rental) error {
+	if currency == "" || s.prices == nil {
+		return nil
+	}
+	if !currencyTypes.IsSupported(currency) {
+		return fmt.Errorf("%w: %s is not supported", ErrPriceConversion, currency)
+	}
+
+	for i := range rentals {
+		amount, rate, err := s.prices.Convert(ctx, rentals[i].Price, rentals[i].Currency, currency)
+		if err != nil {
+			log.Printf("Failed to convert the price of rental %s: %v", rentals[i].ID.Hex(), err)
+			continue
+		}
+		rentals[i].DisplayPrice = &types.DisplayPrice{Amount: amount, Currency: currency, Rate: rate}
+	}
+	return nil
+}
+
+// normalisePriceRange converts the price bounds of a query to TND, in which the prices are compared
+func (s *rentalService) normalisePriceRange(ctx context.Context, query *types.RentalQuery) error {
+	if s.prices == nil {
+		return nil
+	}
+	err := utils.NormalisePriceRange(query, func(amount int64, currency string) (int64, error) {
+		return s.prices.ToBase(ctx, amount, currency)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPriceConversion, err)
+	}
+	return nil
+}
+
+// setPriceTND computes the normalised price of a rental, left unset while its currency has no rate
+func (s *rentalService) setPriceTND(ctx context.Context, rental *types.Rental) {
+	rental.PriceTND = nil
+	if rental.Currency == currencyTypes.BaseCurrency {
+		price := rental.Price
+		rental.PriceTND = &price
+		return
+	}
+	if s.prices == nil {
+		return
+	}
+
+	price, err := s.prices.ToBase(ctx, rental.Price, rental.Currency)
+	if err != nil {
+		log.Printf("Failed to normalise the price of rental %s: %v", rental.ID.Hex(), err)
+		return
+	}
+	rental.PriceTND = &price
+}
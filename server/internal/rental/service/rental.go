@@ -4,33 +4,125 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"server/internal/ical"
 	"server/internal/rental/repository"
 	types "server/internal/rental/types"
+	"server/internal/rental/utils"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrInvalidCursor is returned when the page cursor sent by the client cannot be used
+var ErrInvalidCursor = repository.ErrInvalidCursor
+
 type RentalService interface {
 	AddRental(ctx context.Context, rental types.Rental) error
-	GetAllRentals(ctx context.Context) ([]types.Rental, error)
+	GetAllRentals(ctx context.Context, query types.RentalQuery, page types.PageRequest) (*types.RentalPage, error)
+	GetRentalMarkers(ctx context.Context, query types.RentalQuery) (*types.RentalMarkers, error)
+	GetRentalClusters(ctx context.Context, query types.RentalQuery, zoom int) ([]types.RentalCluster, error)
+	GetRentalFacets(ctx context.Context, query types.RentalQuery) (*types.RentalFacets, error)
 	GetRentalByID(ctx context.Context, id string) (*types.Rental, error)
-	GetRentalsByUserID(ctx context.Context, userID string) ([]types.Rental, error) // New Method
-	UpdateRental(ctx context.Context, id string, updatedData types.Rental) error
-	DeleteRental(ctx context.Context, id string) error
+	ConvertPrices(ctx context.Context, currency string, rentals []types.Rental) error
+	GetRentalsByUserID(ctx context.Context, userID string, publishedOnly bool, page types.PageRequest) (*types.RentalPage, error)
+	UpdateRental(ctx context.Context, id string, updatedData types.Rental, version int64, actor primitive.ObjectID) error
+	PatchRental(ctx context.Context, id string, patched types.Rental, version int64, actor primitive.ObjectID) error
+	DeleteRental(ctx context.Context, id string, actor primitive.ObjectID) error
+	GetDeletedRentalByID(ctx context.Context, id string) (*types.Rental, error)
+	RestoreRental(ctx context.Context, id string, actor primitive.ObjectID) error
+	PurgeDeletedRentals(ctx context.Context, olderThan time.Duration) (int, error)
+	ApproveRental(ctx context.Context, id string, adminID primitive.ObjectID) error
+	DeclineRental(ctx context.Context, id string, adminID primitive.ObjectID, reason string) error
+	GetPendingRentals(ctx context.Context, page types.PageRequest) (*types.RentalPage, error)
+	GetRentalHistory(ctx context.Context, id string) ([]types.AuditEntry, error)
+	AddAvailabilityBlock(ctx context.Context, id string, block types.AvailabilityBlock, actor primitive.ObjectID) (*types.AvailabilityBlock, error)
+	RemoveAvailabilityBlock(ctx context.Context, id string, blockID string, actor primitive.ObjectID) error
+	ImportCalendar(ctx context.Context, id string, source string, calendar io.Reader, actor primitive.ObjectID) (int, error)
+	ImportCalendarURL(ctx context.Context, id string, feedURL string, actor primitive.ObjectID) (int, error)
+	SyncCalendarFeeds(ctx context.Context) (int, error)
+	GetRentalCalendar(rental types.Rental) *ical.Calendar
+	SaveDraft(ctx context.Context, rental types.Rental) error
+	UpdateDraft(ctx context.Context, id string, draft types.Rental, version int64, actor primitive.ObjectID) error
+	GetDrafts(ctx context.Context, userID primitive.ObjectID, page types.PageRequest) (*types.RentalPage, error)
+	PublishRental(ctx context.Context, id string, at *time.Time, actor primitive.ObjectID) error
+	PublishDueDrafts(ctx context.Context) (int, error)
+	RenewRental(ctx context.Context, id string, actor primitive.ObjectID) (*types.Rental, error)
+	ExpireRentals(ctx context.Context) (int, error)
+	GetExpiringRentals(ctx context.Context, userID primitive.ObjectID, page types.PageRequest) (*types.RentalPage, error)
+	ImportRentals(ctx context.Context, src io.Reader, format string, owner primitive.ObjectID, dryRun bool) (*types.ImportReport, error)
+	ExportRentals(ctx context.Context, query types.RentalQuery, owner *primitive.ObjectID, fn func(types.Rental) error) error
+	GetSuspectedDuplicates(ctx context.Context, page types.PageRequest) (*types.RentalPage, error)
+	DismissDuplicate(ctx context.Context, id, otherID string, adminID primitive.ObjectID) error
+}
+
+// publicStatuses are the statuses visible in public searches
+var publicStatuses = []types.Status{types.Agreed}
+
+// RentalObserver is notified when a rental is published, i.e. becomes visible in search
+type RentalObserver interface {
+	RentalPublished(ctx context.Context, rental types.Rental)
 }
 
 type rentalService struct {
-	repo repository.RentalRepository
+	repo      repository.RentalRepository
+	audit     repository.AuditRepository
+	prices    PriceConverter
+	expiry    types.ExpiryPolicy
+	observers []RentalObserver
 }
 
-func NewRentalService(repo repository.RentalRepository) RentalService {
-	return &rentalService{repo: repo}
+func NewRentalService(repo repository.RentalRepository, audit repository.AuditRepository, prices PriceConverter, expiry types.ExpiryPolicy, observers ...RentalObserver) RentalService {
+	return &rentalService{repo: repo, audit: audit, prices: prices, expiry: expiry, observers: observers}
 }
 
-// AddRental validates and adds a new rental
+// AddRental validates and adds a new rental, recording its creator in the audit trail
 func (s *rentalService) AddRental(ctx context.Context, rental types.Rental) error {
 	// Validate mandatory fields
 
 	fmt.Println(rental)
 
+	if err := checkMandatoryFields(rental); err != nil {
+		return err
+	}
+
+	// New rentals always wait for moderation
+	rental.Status = types.Pending
+	rental.Moderation = nil
+	rental.ExpiresAt = nil
+
+	// Apply default values
+	if rental.Currency == "" {
+		rental.Currency = "TND"
+	}
+	if !rental.Available {
+		rental.Available = true
+	}
+	if rental.Standing == "" {
+		rental.Standing = types.Standard
+	}
+	if rental.ID.IsZero() {
+		rental.ID = primitive.NewObjectID()
+	}
+	s.setPriceTND(ctx, &rental)
+	rental.ImageHashes = hashImages(rental.Images)
+	rental.Duplicates = nil
+
+	if err := s.repo.AddRental(ctx, rental); err != nil {
+		return err
+	}
+
+	s.record(ctx, types.AuditCreate, rental.CreatedBy, nil, &rental)
+	s.checkDuplicates(ctx, rental)
+	return nil
+}
+
+// checkMandatoryFields checks the fields every rental needs before it goes to moderation
+func checkMandatoryFields(rental types.Rental) error {
 	if rental.Name == "" {
 		return errors.New("rental name cannot be empty")
 	}
@@ -43,27 +135,124 @@ func (s *rentalService) AddRental(ctx context.Context, rental types.Rental) erro
 	if !rental.Amenities.AirConditioning || !rental.Amenities.Heating {
 		return errors.New("air conditioning and heating are required amenities")
 	}
+	return nil
+}
 
-	// Apply default values
-	if rental.Status == "" {
-		rental.Status = types.Pending
+// observerTimeout bounds the work of the observers of a single publication
+const observerTimeout = time.Minute
+
+// notifyPublished hands the stored version of a freshly published rental to the observers.
+// They run in the background, matching a rental against every saved search must not hold up the request publishing it.
+func (s *rentalService) notifyPublished(ctx context.Context, id primitive.ObjectID) {
+	if len(s.observers) == 0 {
+		return
 	}
-	if rental.Currency == "" {
-		rental.Currency = "TND"
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), observerTimeout)
+		defer cancel()
+
+		rental, err := s.repo.GetRentalByID(ctx, id.Hex())
+		if err != nil || rental == nil {
+			log.Printf("Failed to load published rental %s: %v", id.Hex(), err)
+			return
+		}
+		for _, observer := range s.observers {
+			observer.RentalPublished(ctx, *rental)
+		}
+	}()
+}
+
+// GetAllRentals retrieves one page of the rentals matching the given query.
+// Text searches also get the matched snippets of each rental highlighted,
+// and searches in a currency get the prices converted to it.
+func (s *rentalService) GetAllRentals(ctx context.Context, query types.RentalQuery, page types.PageRequest) (*types.RentalPage, error) {
+	query.Status = publicStatuses
+	if err := s.normalisePriceRange(ctx, &query); err != nil {
+		return nil, err
 	}
-	if !rental.Available {
-		rental.Available = true
+	result, err := s.repo.GetAllRentals(ctx, query, page)
+	if err != nil {
+		return nil, err
 	}
-	if rental.Standing == "" {
-		rental.Standing = types.Standard
+	if err := s.ConvertPrices(ctx, query.Currency, result.Items); err != nil {
+		return nil, err
+	}
+
+	if query.Text != "" {
+		terms := utils.SearchTerms(query.Text)
+		for i := range result.Items {
+			result.Items[i].Highlights = highlightRental(result.Items[i], terms)
+		}
+	}
+
+	return result, nil
+}
+
+// highlightRental returns the highlighted snippet of every searchable field that matches the terms
+func highlightRental(rental types.Rental, terms []string) map[string]string {
+	fields := map[string]string{
+		"name":        rental.Name,
+		"description": rental.Description,
+		"tags":        strings.Join(rental.Tags, ", "),
+		"fullAddress": rental.Address.FullAddress,
 	}
 
-	return s.repo.AddRental(ctx, rental)
+	highlights := map[string]string{}
+	for field, text := range fields {
+		if snippet, ok := utils.Highlight(text, terms); ok {
+			highlights[field] = snippet
+		}
+	}
+	return highlights
+}
+
+// GetRentalMarkers retrieves the map markers of the rentals inside the queried area,
+// at most MaxMarkers of them, flagging the response as truncated when there are more
+func (s *rentalService) GetRentalMarkers(ctx context.Context, query types.RentalQuery) (*types.RentalMarkers, error) {
+	if query.Within == nil {
+		return nil, errors.New("an area is required to retrieve markers")
+	}
+	query.Status = publicStatuses
+	if err := s.normalisePriceRange(ctx, &query); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra marker to know whether the viewport holds more
+	markers, err := s.repo.GetRentalMarkers(ctx, query, types.MaxMarkers+1)
+	if err != nil {
+		return nil, err
+	}
+	result := &types.RentalMarkers{Markers: markers}
+	if int64(len(markers)) > types.MaxMarkers {
+		result.Markers, result.Truncated = markers[:types.MaxMarkers], true
+	}
+	return result, nil
 }
 
-// GetAllRentals retrieves all rentals
-func (s *rentalService) GetAllRentals(ctx context.Context) ([]types.Rental, error) {
-	return s.repo.GetAllRentals(ctx)
+// GetRentalClusters groups the rentals inside the queried area into clusters sized for the zoom level.
+// Clusters get smaller as the zoom level grows, until each of them holds a single rental.
+func (s *rentalService) GetRentalClusters(ctx context.Context, query types.RentalQuery, zoom int) ([]types.RentalCluster, error) {
+	if query.Within == nil {
+		return nil, errors.New("an area is required to retrieve clusters")
+	}
+	if zoom < 0 || zoom > types.MaxClusterZoom {
+		return nil, fmt.Errorf("zoom must be between 0 and %d", types.MaxClusterZoom)
+	}
+	query.Status = publicStatuses
+	if err := s.normalisePriceRange(ctx, &query); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRentalClusters(ctx, query, types.ClusterCellSize(zoom))
+}
+
+// GetRentalFacets counts the rentals matching each filter option, given the other active filters
+func (s *rentalService) GetRentalFacets(ctx context.Context, query types.RentalQuery) (*types.RentalFacets, error) {
+	query.Status = publicStatuses
+	if err := s.normalisePriceRange(ctx, &query); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRentalFacets(ctx, query)
 }
 
 // GetRentalByID retrieves a single rental by its ID
@@ -74,37 +263,26 @@ func (s *rentalService) GetRentalByID(ctx context.Context, id string) (*types.Re
 	return s.repo.GetRentalByID(ctx, id)
 }
 
-// GetRentalsByUserID retrieves all rentals for a specific user
-func (s *rentalService) GetRentalsByUserID(ctx context.Context, userID string) ([]types.Rental, error) {
+// GetRentalsByUserID retrieves one page of rentals for a specific user, only the published ones if publishedOnly is set
+func (s *rentalService) GetRentalsByUserID(ctx context.Context, userID string, publishedOnly bool, page types.PageRequest) (*types.RentalPage, error) {
 	if userID == "" {
 		return nil, errors.New("userID cannot be empty")
 	}
-	return s.repo.GetRentalsByUserID(ctx, userID)
+	return s.repo.GetRentalsByUserID(ctx, userID, publishedOnly, page)
 }
 
-// UpdateRental updates an existing rental
-func (s *rentalService) UpdateRental(ctx context.Context, id string, updatedData types.Rental) error {
+// UpdateRental updates an existing rental on behalf of actor.
+// version is the version the changes are based on, ErrVersionConflict is returned if the rental moved past it.
+func (s *rentalService) UpdateRental(ctx context.Context, id string, updatedData types.Rental, version int64, actor primitive.ObjectID) error {
 	// Validate mandatory fields
 	if id == "" {
 		return errors.New("id cannot be empty")
 	}
-	if updatedData.Name == "" {
-		return errors.New("rental name cannot be empty")
-	}
-	if updatedData.Address.StreetNumber == "" || updatedData.Address.Street == "" || updatedData.Address.City == "" || updatedData.Address.Country == "" {
-		return errors.New("address fields cannot be empty")
-	}
-	if updatedData.Geometry.Lat == "" || updatedData.Geometry.Lng == "" {
-		return errors.New("geometry fields (latitude and longitude) cannot be empty")
-	}
-	if !updatedData.Amenities.AirConditioning || !updatedData.Amenities.Heating {
-		return errors.New("air conditioning and heating are required amenities")
+	if err := checkMandatoryFields(updatedData); err != nil {
+		return err
 	}
 
 	// Apply default values if not set
-	if updatedData.Status == "" {
-		updatedData.Status = types.Pending
-	}
 	if updatedData.Currency == "" {
 		updatedData.Currency = "TND"
 	}
@@ -115,13 +293,136 @@ func (s *rentalService) UpdateRental(ctx context.Context, id string, updatedData
 		updatedData.Standing = types.Standard
 	}
 
-	return s.repo.UpdateRental(ctx, id, updatedData)
+	return s.update(ctx, id, updatedData, version, actor)
 }
 
-// DeleteRental deletes a rental by its ID
-func (s *rentalService) DeleteRental(ctx context.Context, id string) error {
+// PatchRental stores a rental that had some of its fields patched on behalf of actor.
+// Unlike UpdateRental it applies no defaults, the fields left out of the patch keep their value.
+// The patched fields are expected to be validated already.
+func (s *rentalService) PatchRental(ctx context.Context, id string, patched types.Rental, version int64, actor primitive.ObjectID) error {
 	if id == "" {
 		return errors.New("id cannot be empty")
 	}
-	return s.repo.DeleteRental(ctx, id)
+
+	// The full address is derived from the other address fields, let the repository rebuild it
+	patched.Address.FullAddress = ""
+	patched.UpdatedAt = time.Now()
+	patched.UpdatedBy = actor
+	patched.LastUpdatedBy = actor
+
+	return s.update(ctx, id, patched, version, actor)
+}
+
+// update stores a new version of a rental and records the change in the audit trail
+func (s *rentalService) update(ctx context.Context, id string, updatedData types.Rental, version int64, actor primitive.ObjectID) error {
+	previous, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return ErrRentalNotFound
+	}
+	if previous.Version != version {
+		return ErrVersionConflict
+	}
+	s.setPriceTND(ctx, &updatedData)
+	updatedData.ImageHashes = hashImages(updatedData.Images)
+	updatedData.Duplicates = previous.Duplicates
+
+	// The status only changes through moderation, except that editing a declined rental resubmits it.
+	// Editing a draft cancels its scheduled publication, the new content has not been validated for it.
+	updatedData.Status = previous.Status
+	updatedData.Moderation = previous.Moderation
+	updatedData.ExpiresAt = previous.ExpiresAt
+	updatedData.PublishAt = nil
+	if previous.Status == types.Declined {
+		updatedData.Status = types.Pending
+	}
+
+	if err := s.repo.UpdateRental(ctx, id, updatedData, version); err != nil {
+		return err
+	}
+
+	updatedData.ID = previous.ID
+	s.record(ctx, types.AuditUpdate, actor, previous, &updatedData)
+	return nil
+}
+
+// DeleteRental soft deletes a rental by its ID on behalf of actor
+func (s *rentalService) DeleteRental(ctx context.Context, id string, actor primitive.ObjectID) error {
+	if id == "" {
+		return errors.New("id cannot be empty")
+	}
+
+	previous, err := s.repo.GetRentalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return ErrRentalNotFound
+	}
+
+	if err := s.repo.DeleteRental(ctx, id); err != nil {
+		return err
+	}
+
+	deleted := *previous
+	deletedAt := time.Now()
+	deleted.DeletedAt = &deletedAt
+	s.record(ctx, types.AuditDelete, actor, previous, &deleted)
+	return nil
+}
+
+// GetDeletedRentalByID retrieves a soft deleted rental by its ID
+func (s *rentalService) GetDeletedRentalByID(ctx context.Context, id string) (*types.Rental, error) {
+	if id == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+	return s.repo.GetDeletedRentalByID(ctx, id)
+}
+
+// RestoreRental brings back a soft deleted rental on behalf of actor
+func (s *rentalService) RestoreRental(ctx context.Context, id string, actor primitive.ObjectID) error {
+	if id == "" {
+		return errors.New("id cannot be empty")
+	}
+
+	previous, err := s.repo.GetDeletedRentalByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return ErrRentalNotFound
+	}
+
+	if err := s.repo.RestoreRental(ctx, id); err != nil {
+		return err
+	}
+
+	restored := *previous
+	restored.DeletedAt = nil
+	s.record(ctx, types.AuditRestore, actor, previous, &restored)
+	return nil
+}
+
+// PurgeDeletedRentals permanently removes the rentals soft deleted for longer than olderThan,
+// along with their image folders, and returns how many were removed.
+func (s *rentalService) PurgeDeletedRentals(ctx context.Context, olderThan time.Duration) (int, error) {
+	if olderThan < 0 {
+		return 0, errors.New("retention period cannot be negative")
+	}
+
+	ids, err := s.repo.PurgeDeletedRentals(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		rentalFolder := filepath.Join(utils.GetBasePath(), id.Hex())
+		if err := os.RemoveAll(rentalFolder); err != nil {
+			log.Printf("Failed to remove images of purged rental %s: %v", id.Hex(), err)
+		}
+	}
+
+	return len(ids), nil
 }
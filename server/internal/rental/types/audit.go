@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate       AuditAction = "create"
+	AuditUpdate       AuditAction = "update"
+	AuditStatusChange AuditAction = "status"
+	AuditDelete       AuditAction = "delete"
+	AuditRestore      AuditAction = "restore"
+)
+
+// FieldChange is the old and new value of a single field, named by its dotted BSON path (e.g. "address.city")
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	Old   interface{} `json:"old" bson:"old"`
+	New   interface{} `json:"new" bson:"new"`
+}
+
+// AuditEntry records who changed what on a rental, and when
+type AuditEntry struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RentalID primitive.ObjectID `json:"rentalId" bson:"rentalId"`
+	Action   AuditAction        `json:"action" bson:"action"`
+	Actor    primitive.ObjectID `json:"actor" bson:"actor"`
+	Changes  []FieldChange      `json:"changes" bson:"changes"`
+	At       time.Time          `json:"at" bson:"at"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BlockKind string
+
+const (
+	Blocked BlockKind = "blocked" // Closed by the landlord, or booked on another site
+	Booked  BlockKind = "booked"  // Taken by a reservation
+)
+
+const (
+	// ManualSource marks the blocks entered by hand, as opposed to the ones imported from a calendar
+	ManualSource = "manual"
+	// ReservationSource marks the blocks held by accepted reservations, which share the ID of their reservation
+	ReservationSource = "reservation"
+)
+
+// AvailabilityBlock is a period during which a rental cannot be booked.
+// Periods are whole days, Start is inclusive and End is exclusive, both at midnight UTC.
+type AvailabilityBlock struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id"`
+	Kind   BlockKind          `json:"kind" bson:"kind"`
+	Start  time.Time          `json:"start" bson:"start"`
+	End    time.Time          `json:"end" bson:"end"`
+	Note   string             `json:"note,omitempty" bson:"note,omitempty"` // Shown on the listing
+	Source string             `json:"-" bson:"source"`                      // ManualSource, or the URL or file name of the imported calendar. Feed URLs often embed a secret token, they are never served.
+	UID    string             `json:"-" bson:"uid,omitempty"`               // iCalendar UID of an imported event
+}
+
+// FeedSyncInterval is how often the calendar feeds of the rentals are imported again
+const FeedSyncInterval = 15 * time.Minute
+
+// CalendarFeed is the calendar of another listing site, imported again periodically to keep the rental calendar in sync
+type CalendarFeed struct {
+	URL        string             `json:"-" bson:"url"` // Never served, like the source of the blocks it imports
+	ImportedBy primitive.ObjectID `json:"importedBy" bson:"importedBy"`
+	SyncedAt   *time.Time         `json:"syncedAt,omitempty" bson:"syncedAt,omitempty"`
+	Error      string             `json:"error,omitempty" bson:"error,omitempty"` // Why the last sync failed, empty when it succeeded
+}
+
+// DateRange is a period of whole days, Start inclusive and End exclusive
+type DateRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Overlaps reports whether the block shares at least one day with the range
+func (b AvailabilityBlock) Overlaps(r DateRange) bool {
+	return b.Start.Before(r.End) && b.End.After(r.Start)
+}
+
+// Day truncates a time to midnight UTC of its date
+func Day(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"server/internal/geo"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxClusterZoom = 22
+	// ClusterCellsPerTile splits each 256px map tile into a 4x4 grid, i.e. cells of about 64px
+	ClusterCellsPerTile = 4
+)
+
+// RentalCluster groups the rentals that fall into the same grid cell at a zoom level
+type RentalCluster struct {
+	Count       int64               `json:"count"`
+	Centroid    geo.Point           `json:"centroid"`
+	MinPrice    int64               `json:"minPrice"`           // In TND
+	MedianPrice float64             `json:"medianPrice"`        // In TND
+	RentalID    *primitive.ObjectID `json:"rentalId,omitempty"` // Only set when the cluster holds a single rental
+}
+
+// ClusterCellSize returns the size in degrees of a grid cell at the given zoom level
+func ClusterCellSize(zoom int) float64 {
+	return 360 / float64(int64(1)<<uint(zoom)*ClusterCellsPerTile)
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+type Gender string
+
+const (
+	AnyGender Gender = "any"
+	Male      Gender = "male"
+	Female    Gender = "female"
+)
+
+type TitleStatus string
+
+const (
+	Registered   TitleStatus = "registered"   // Land title registered with the property registry
+	InProgress   TitleStatus = "in_progress"  // Registration pending
+	Unregistered TitleStatus = "unregistered" // Deed only
+)
+
+// SharedDetails describes a room in a shared home
+type SharedDetails struct {
+	RoomCount        int64  `json:"roomCount" bson:"roomCount" validate:"required,min=1,max=20"` // Rooms of the home, bedrooms counts the ones offered
+	Occupants        int64  `json:"occupants" bson:"occupants" validate:"min=0,max=20"`          // Current flatmates
+	GenderPreference Gender `json:"genderPreference" bson:"genderPreference" validate:"required,oneof=any male female" default:"any"`
+}
+
+// SaleDetails describes a property for sale
+type SaleDetails struct {
+	SalePrice   int64       `json:"salePrice" bson:"salePrice" validate:"required,min=1"`
+	TitleStatus TitleStatus `json:"titleStatus" bson:"titleStatus" validate:"required,oneof=registered in_progress unregistered"`
+	Negotiable  bool        `json:"negotiable" bson:"negotiable"`
+}
+
+// IndependentDetails describes the lease of a whole home
+type IndependentDetails struct {
+	LeaseMonths int64 `json:"leaseMonths" bson:"leaseMonths" validate:"required,min=1,max=120"`
+	Deposit     int64 `json:"deposit" bson:"deposit" validate:"min=0"` // In the currency of the rental
+}
+
+// ValidateTypeDetails is a struct level validation of Rental: the details matching its type are required
+// and the details of the other types must be empty. Rentals without a type are left to the required tag of Type.
+func ValidateTypeDetails(sl validator.StructLevel) {
+	rental := sl.Current().Interface().(Rental)
+
+	details := []struct {
+		rentalType RentalType
+		set        bool
+		field      interface{}
+		name       string
+	}{
+		{Shared, rental.Shared != nil, rental.Shared, "Shared"},
+		{Sale, rental.Sale != nil, rental.Sale, "Sale"},
+		{Independent, rental.Independent != nil, rental.Independent, "Independent"},
+	}
+	for _, d := range details {
+		switch {
+		case rental.Type == "":
+		case d.rentalType == rental.Type && !d.set:
+			sl.ReportError(d.field, string(d.rentalType), d.name, "required", "")
+		case d.rentalType != rental.Type && d.set:
+			sl.ReportError(d.field, string(d.rentalType), d.name, "excluded", string(rental.Type))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DuplicateRadius      = 15.0 // meters, rentals closer than this are at the same place
+	DuplicatePriceMargin = 0.05 // relative difference under which prices are the same
+	MaxImageHashDistance = 6    // bits, image hashes closer than this are the same picture
+	imageHashBands       = 8    // bands of 8 bits an image hash is split into to look for near copies
+)
+
+// MinSharedHashBands is how many bands two image hashes at most MaxImageHashDistance bits apart have in common at least,
+// each differing bit changing a single band
+const MinSharedHashBands = imageHashBands - MaxImageHashDistance
+
+type DuplicateReason string
+
+const (
+	SameAddress  DuplicateReason = "address"  // Same address once normalised
+	SameLocation DuplicateReason = "location" // Within DuplicateRadius
+	SameListing  DuplicateReason = "listing"  // Same bedrooms and price, only suspect along with the address or the location
+	SameImages   DuplicateReason = "images"   // At least one picture in common
+)
+
+// DuplicateLink ties a rental to another one suspected to be the same flat.
+// Links go both ways, an admin dismisses them when the rentals turn out to be different.
+type DuplicateLink struct {
+	RentalID   primitive.ObjectID `json:"rentalId" bson:"rentalId"`
+	Reasons    []DuplicateReason  `json:"reasons" bson:"reasons"`
+	DetectedAt time.Time          `json:"detectedAt" bson:"detectedAt"`
+}
+
+// Key reduces an address to lower case words, so that spelling the same address
+// with other punctuation or spacing gives the same key
+func (a Address) Key() string {
+	text := strings.Join([]string{a.StreetNumber, a.Street, a.City, a.Country}, " ")
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// ImageHashBands splits image hashes of 16 hex digits into their bands, tagged with their position, e.g. "3:af".
+// Rentals with a picture in common share MinSharedHashBands of them at least. Hashes that cannot be read are skipped.
+func ImageHashBands(hashes []string) []string {
+	var bands []string
+	seen := map[string]bool{}
+	for _, hash := range hashes {
+		if len(hash) != 2*imageHashBands {
+			continue
+		}
+		for i := 0; i < imageHashBands; i++ {
+			band := fmt.Sprintf("%d:%s", i, hash[2*i:2*i+2])
+			if !seen[band] {
+				seen[band] = true
+				bands = append(bands, band)
+			}
+		}
+	}
+	return bands
+}
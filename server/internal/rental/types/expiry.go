@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// DefaultLifetime is how long a published rental of a type without any lifetime, configured or default, stays in search
+const DefaultLifetime = 60 * 24 * time.Hour
+
+// ExpiryPolicy sets how long published rentals stay in search before they expire
+type ExpiryPolicy struct {
+	Lifetimes map[RentalType]time.Duration // Per rental type, those of DefaultExpiryPolicy for the others
+	Warning   time.Duration                // How long before expiry owners are warned
+}
+
+// DefaultExpiryPolicy gives the lifetimes of the rental types whose configured lifetime is missing or zero
+var DefaultExpiryPolicy = ExpiryPolicy{
+	Lifetimes: map[RentalType]time.Duration{
+		Shared:      30 * 24 * time.Hour,
+		Independent: 60 * 24 * time.Hour,
+		Sale:        90 * 24 * time.Hour,
+	},
+	Warning: 7 * 24 * time.Hour,
+}
+
+// Lifetime returns how long a published rental of the given type stays in search
+func (p ExpiryPolicy) Lifetime(rentalType RentalType) time.Duration {
+	if lifetime := p.Lifetimes[rentalType]; lifetime > 0 {
+		return lifetime
+	}
+	if lifetime := DefaultExpiryPolicy.Lifetimes[rentalType]; lifetime > 0 {
+		return lifetime
+	}
+	return DefaultLifetime
+}
+
+// ExpiresAt returns when a rental of the given type published at from expires
+func (p ExpiryPolicy) ExpiresAt(rentalType RentalType, from time.Time) time.Time {
+	return from.Add(p.Lifetime(rentalType))
+}
@@ -0,0 +1,22 @@
+package models
+
+// PriceBuckets are the lower bounds, in TND, of the price ranges counted by the facets endpoint.
+// The last bucket is open ended.
+var PriceBuckets = []int64{0, 500, 1000, 1500, 2000, 3000}
+
+// FacetCount is the number of listings matching one option of a filter
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// RentalFacets holds the counts shown next to each option of the filter drawer.
+// Each facet applies every active filter except its own, so the counts tell how many
+// listings the user would get by picking that option instead.
+type RentalFacets struct {
+	Standing  []FacetCount     `json:"standing"`
+	Bedrooms  []FacetCount     `json:"bedrooms"`
+	Type      []FacetCount     `json:"type"`
+	Price     []FacetCount     `json:"price"`
+	Amenities map[string]int64 `json:"amenities"`
+}
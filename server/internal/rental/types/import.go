@@ -0,0 +1,52 @@
+package models
+
+// MaxImportRows is the number of rentals a single import can hold
+const MaxImportRows = 1000
+
+type ImportOutcome string
+
+const (
+	ImportCreated ImportOutcome = "created"
+	ImportSkipped ImportOutcome = "skipped" // Already listed by the owner, or repeated in the file
+	ImportFailed  ImportOutcome = "failed"
+)
+
+// ImportRow is a rental read from an import file, or the reason it could not be read
+type ImportRow struct {
+	Line   int // Line of the row in the file
+	Rental Rental
+	Err    error
+}
+
+// ImportRowResult is the outcome of importing one row
+type ImportRowResult struct {
+	Line    int               `json:"line"`
+	Name    string            `json:"name,omitempty"`
+	Outcome ImportOutcome     `json:"outcome"`
+	ID      string            `json:"id,omitempty"`      // Set for created rentals, and the listed one for skipped rentals
+	Error   string            `json:"error,omitempty"`   // Why the row was skipped or failed
+	Details map[string]string `json:"details,omitempty"` // Invalid fields of a failed row, by JSON path
+}
+
+// ImportReport lists the outcome of every row of an import.
+// Nothing is written in a dry run, created then means the row would be created.
+type ImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// Add records the outcome of a row
+func (r *ImportReport) Add(result ImportRowResult) {
+	switch result.Outcome {
+	case ImportCreated:
+		r.Created++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
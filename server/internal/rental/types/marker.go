@@ -0,0 +1,26 @@
+package models
+
+import (
+	"server/internal/geo"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxMarkers caps the number of markers returned for a single viewport
+const MaxMarkers int64 = 1000
+
+// RentalMarkers is the response of a marker request.
+// Truncated is set when the viewport holds more than MaxMarkers rentals, the client should then switch to clusters.
+type RentalMarkers struct {
+	Markers   []RentalMarker `json:"markers"`
+	Truncated bool           `json:"truncated"`
+}
+
+// RentalMarker is the light projection of a rental drawn on the map
+type RentalMarker struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id"`
+	Price    int64              `json:"price" bson:"price"`
+	Currency string             `json:"currency" bson:"currency"`
+	Standing Standing           `json:"standing" bson:"standing"`
+	Location geo.Point          `json:"location" bson:"location"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// SortField is a rental field that listings can be ordered by
+type SortField string
+
+const (
+	SortByPrice     SortField = "price"
+	SortByCreatedAt SortField = "createdAt"
+	SortByAreaSize  SortField = "areaSize"
+	SortByDistance  SortField = "distance"  // Only valid for searches around a point
+	SortByRelevance SortField = "relevance" // Only valid for text searches, always best match first
+)
+
+const (
+	DefaultPageLimit int64 = 20
+	MaxPageLimit     int64 = 100
+)
+
+// PageRequest describes which slice of a listing to return.
+// Cursor is the opaque NextCursor of the previous page, empty for the first page.
+type PageRequest struct {
+	Limit        int64
+	Cursor       string
+	Sort         SortField
+	Descending   bool
+	IncludeTotal bool
+}
+
+// RentalPage is the response envelope of paginated rental listings
+type RentalPage struct {
+	Items      []Rental `json:"items"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+	Total      *int64   `json:"total,omitempty"`
+}
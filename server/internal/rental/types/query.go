@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"server/internal/geo"
+)
+
+// RentalQuery holds the filters accepted by rental searches.
+// A nil pointer or an empty slice means the filter is not applied.
+type RentalQuery struct {
+	Text string `json:"text,omitempty"` // Full-text search over name, description, tags and address
+
+	// Status is set by the service, public searches only ever see agreed rentals
+	Status []Status `json:"-"`
+
+	// Currency of the price bounds and of the display prices, TND when empty.
+	// The service converts the bounds to TND before searching.
+	Currency     string `json:"currency,omitempty"`
+	MinPrice     *int64 `json:"minPrice,omitempty"`
+	MaxPrice     *int64 `json:"maxPrice,omitempty"`
+	MinBedrooms  *int64 `json:"minBedrooms,omitempty"`
+	MaxBedrooms  *int64 `json:"maxBedrooms,omitempty"`
+	MinBathrooms *int64 `json:"minBathrooms,omitempty"`
+	MaxBathrooms *int64 `json:"maxBathrooms,omitempty"`
+	MinAreaSize  *int64 `json:"minAreaSize,omitempty"`
+	MaxAreaSize  *int64 `json:"maxAreaSize,omitempty"`
+
+	Standing  []Standing   `json:"standing,omitempty"`
+	Type      []RentalType `json:"type,omitempty"`
+	Available *bool        `json:"available,omitempty"`
+	Tags      []string     `json:"tags,omitempty"` // A rental must carry every tag
+
+	// AvailableFrom keeps rentals that are available on or before this date
+	AvailableFrom *time.Time `json:"availableFrom,omitempty"`
+	// AvailableBetween keeps rentals whose calendar is free over the whole period
+	AvailableBetween *DateRange `json:"availableBetween,omitempty"`
+
+	Amenities AmenitiesQuery `json:"amenities,omitempty"`
+	Rules     RulesQuery     `json:"rules,omitempty"`
+
+	// Filters on the details of a rental type only match rentals of that type
+	Shared      SharedQuery      `json:"shared,omitempty"`
+	Sale        SaleQuery        `json:"sale,omitempty"`
+	Independent IndependentQuery `json:"independent,omitempty"`
+
+	Near   *NearQuery   `json:"near,omitempty"`
+	Within *geo.Polygon `json:"within,omitempty"` // Viewport or area drawn on the map
+}
+
+const (
+	DefaultNearRadius = 5000.0  // meters
+	MaxNearRadius     = 50000.0 // meters
+)
+
+// NearQuery keeps rentals within Radius meters of Point
+type NearQuery struct {
+	Point  geo.Point `json:"point"`
+	Radius float64   `json:"radius"`
+}
+
+// AmenitiesQuery filters on individual amenity flags
+type AmenitiesQuery struct {
+	AirConditioning *bool `json:"airConditioning,omitempty"`
+	Heating         *bool `json:"heating,omitempty"`
+	Refrigerator    *bool `json:"refrigerator,omitempty"`
+	Parking         *bool `json:"parking,omitempty"`
+}
+
+// RulesQuery filters on individual house rule flags
+type RulesQuery struct {
+	PetsAllowed    *bool `json:"petsAllowed,omitempty"`
+	PartiesAllowed *bool `json:"partiesAllowed,omitempty"`
+	SmokingAllowed *bool `json:"smokingAllowed,omitempty"`
+}
+
+// SharedQuery filters on the details of shared rentals
+type SharedQuery struct {
+	MinRoomCount     *int64   `json:"minRoomCount,omitempty"`
+	MaxRoomCount     *int64   `json:"maxRoomCount,omitempty"`
+	MaxOccupants     *int64   `json:"maxOccupants,omitempty"`
+	GenderPreference []Gender `json:"genderPreference,omitempty"`
+}
+
+// SaleQuery filters on the details of sale listings
+type SaleQuery struct {
+	MinSalePrice *int64        `json:"minSalePrice,omitempty"`
+	MaxSalePrice *int64        `json:"maxSalePrice,omitempty"`
+	TitleStatus  []TitleStatus `json:"titleStatus,omitempty"`
+	Negotiable   *bool         `json:"negotiable,omitempty"`
+}
+
+// IndependentQuery filters on the details of independent rentals
+type IndependentQuery struct {
+	MinLeaseMonths *int64 `json:"minLeaseMonths,omitempty"`
+	MaxLeaseMonths *int64 `json:"maxLeaseMonths,omitempty"`
+	MaxDeposit     *int64 `json:"maxDeposit,omitempty"`
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"server/internal/geo"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -28,19 +30,50 @@ const (
 	Agreed   Status = "agreed"
 	Declined Status = "declined"
 	Pending  Status = "pending"
+	Draft    Status = "draft"   // Incomplete, only visible to its owner until published
+	Expired  Status = "expired" // Out of search once its lifetime is over, until its owner renews it
 )
 
+// statusTransitions lists the moderation moves allowed from each status.
+// Publishing a draft submits it for moderation, pending rentals are approved or declined by an admin,
+// a declined rental goes back to pending once its owner edits it, and an approved rental can still be taken down with a decline.
+// An approved rental expires at the end of its lifetime and gets back in search when renewed.
+var statusTransitions = map[Status][]Status{
+	Draft:    {Pending},
+	Pending:  {Agreed, Declined},
+	Declined: {Pending},
+	Agreed:   {Declined, Expired},
+	Expired:  {Agreed},
+}
+
+// CanTransitionTo reports whether a rental can move from status s to next
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Moderation records the last moderation decision on a rental
+type Moderation struct {
+	Reason      string             `json:"reason,omitempty" bson:"reason,omitempty"` // Required when declining, shown to the landlord
+	ModeratedBy primitive.ObjectID `json:"moderatedBy" bson:"moderatedBy"`
+	ModeratedAt time.Time          `json:"moderatedAt" bson:"moderatedAt"`
+}
+
 type Address struct {
 	StreetNumber string `json:"streetNumber" bson:"streetNumber" validate:"required"`
 	Street       string `json:"street" bson:"street" validate:"required"`
 	City         string `json:"city" bson:"city" validate:"required"`
 	Country      string `json:"country" bson:"country" validate:"required"`
-	FullAddress  string `json:"fullAddress" bson:"fullAddress""`
+	FullAddress  string `json:"fullAddress" bson:"fullAddress"`
 }
 
 type Geometry struct {
-	Lat string `json:"lat" bson:"lat" validate:"required,latitude,min=-30,max=37.5"`
-	Lng string `json:"lng" bson:"lng" validate:"required,longitude,min=7,max=11.5"`
+	Lat string `json:"lat" bson:"lat" validate:"required,latitude"`
+	Lng string `json:"lng" bson:"lng" validate:"required,longitude"`
 }
 
 type Amenities struct {
@@ -57,30 +90,63 @@ type Rules struct {
 }
 
 type Rental struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name          string             `json:"name" bson:"name" validate:"required,min=3,max=100"`
-	Address       Address            `json:"address" bson:"address"`
-	Geometry      Geometry           `json:"geometry" bson:"geometry"`
-	Images        []string           `json:"images" bson:"images" validate:"dive,url,max=10"` // URLs or file paths for uploaded images
-	AgreeToTerms  bool               `json:"agreeToTerms" bson:"agreeToTerms" validate:"required"`
-	Status        Status             `json:"status" bson:"status" validate:"required,oneof=agreed declined pending" default:"pending"`
-	Description   string             `json:"description" bson:"description" validate:"required,max=500"`
-	Price         int64              `json:"price" bson:"price" validate:"required,min=0"`
-	Currency      string             `json:"currency" bson:"currency" validate:"required,oneof=TND USD EUR" default:"TND"`
-	Bedrooms      int64              `json:"bedrooms" bson:"bedrooms" validate:"required,min=0"`
-	Bathrooms     int64              `json:"bathrooms" bson:"bathrooms" validate:"required,min=0"`
-	AreaSize      int64              `json:"areaSize" bson:"areaSize" validate:"required,min=0"`
-	Available     bool               `json:"available" bson:"available" default:"true"`
-	AvailableFrom time.Time          `json:"availableFrom" bson:"availableFrom" validate:"required"`
-	Tags          []string           `json:"tags" bson:"tags" validate:"dive,min=1,max=50"`
-	Type          RentalType         `json:"type" bson:"type" validate:"required,oneof=shared independent sale"`
-	Standing      Standing           `json:"standing" bson:"standing" validate:"required,oneof=economy standard luxury" default:"standard"`
-	Amenities     Amenities          `json:"amenities" bson:"amenities"`
-	Rules         Rules              `json:"rules" bson:"rules"`
-	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt" validate:"required"`
-	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt" validate:"required"`
-	CreatedBy     primitive.ObjectID `json:"createdBy" bson:"createdBy" validate:"required"`         // Reference to User ID
-	UpdatedBy     primitive.ObjectID `json:"updatedBy" bson:"updatedBy" validate:"required"`         // Reference to User ID
-	DeletedAt     *time.Time         `json:"deletedAt" bson:"deletedAt"`                             // Soft delete field
-	LastUpdatedBy primitive.ObjectID `json:"lastUpdatedBy" bson:"lastUpdatedBy" validate:"required"` // Audit logging
+	ID            primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Name          string              `json:"name" bson:"name" validate:"required,min=3,max=100"`
+	Address       Address             `json:"address" bson:"address"`
+	Geometry      Geometry            `json:"geometry" bson:"geometry"`
+	Location      *geo.Point          `json:"location,omitempty" bson:"location,omitempty"`                        // GeoJSON copy of Geometry, backs the 2dsphere index
+	Images        []string            `json:"images" bson:"images" validate:"required,min=1,max=10,dive,required"` // URLs or file paths for uploaded images
+	ImageHashes   []string            `json:"-" bson:"imageHashes,omitempty"`                                      // Average hashes of the uploaded images, for duplicate detection
+	ImageBands    []string            `json:"-" bson:"imageBands,omitempty"`                                       // Bands of the image hashes, see ImageHashBands
+	AddressKey    string              `json:"-" bson:"addressKey,omitempty"`                                       // Normalised address, see Address.Key
+	Duplicates    []DuplicateLink     `json:"duplicates,omitempty" bson:"duplicates,omitempty"`                    // Rentals suspected to be the same flat
+	AgreeToTerms  bool                `json:"agreeToTerms" bson:"agreeToTerms" validate:"required"`
+	Status        Status              `json:"status" bson:"status" validate:"required,oneof=agreed declined pending draft expired" default:"pending"`
+	Moderation    *Moderation         `json:"moderation,omitempty" bson:"moderation,omitempty"`
+	PublishAt     *time.Time          `json:"publishAt,omitempty" bson:"publishAt,omitempty"` // Scheduled publication of a draft
+	ExpiresAt     *time.Time          `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"` // Set when the rental is approved or renewed
+	Description   string              `json:"description" bson:"description" validate:"required,max=500"`
+	Price         int64               `json:"price" bson:"price" validate:"required,min=0"`
+	PriceTND      *int64              `json:"priceTND,omitempty" bson:"priceTND,omitempty"` // Price converted to TND, which searches filter and sort on. Unset while the currency has no rate.
+	Currency      string              `json:"currency" bson:"currency" validate:"required,oneof=TND USD EUR" default:"TND"`
+	Bedrooms      int64               `json:"bedrooms" bson:"bedrooms" validate:"min=0"`
+	Bathrooms     int64               `json:"bathrooms" bson:"bathrooms" validate:"min=0"`
+	AreaSize      int64               `json:"areaSize" bson:"areaSize" validate:"required,min=0"`
+	Available     bool                `json:"available" bson:"available" default:"true"`
+	AvailableFrom time.Time           `json:"availableFrom" bson:"availableFrom" validate:"required"`
+	Availability  []AvailabilityBlock `json:"availability" bson:"availability"` // Calendar of the periods the rental cannot be booked
+	CalendarFeeds []CalendarFeed      `json:"-" bson:"calendarFeeds,omitempty"` // Feeds synced into the availability
+	Tags          []string            `json:"tags" bson:"tags" validate:"dive,min=1,max=50"`
+	Type          RentalType          `json:"type" bson:"type" validate:"required,oneof=shared independent sale"`
+	Shared        *SharedDetails      `json:"shared,omitempty" bson:"shared,omitempty"`           // Set for shared rentals only
+	Sale          *SaleDetails        `json:"sale,omitempty" bson:"sale,omitempty"`               // Set for sale listings only
+	Independent   *IndependentDetails `json:"independent,omitempty" bson:"independent,omitempty"` // Set for independent rentals only
+	Standing      Standing            `json:"standing" bson:"standing" validate:"required,oneof=economy standard luxury" default:"standard"`
+	Amenities     Amenities           `json:"amenities" bson:"amenities"`
+	Rules         Rules               `json:"rules" bson:"rules"`
+	CreatedAt     time.Time           `json:"createdAt" bson:"createdAt" validate:"required"`
+	UpdatedAt     time.Time           `json:"updatedAt" bson:"updatedAt" validate:"required"`
+	CreatedBy     primitive.ObjectID  `json:"createdBy" bson:"createdBy" validate:"required"`         // Reference to User ID
+	UpdatedBy     primitive.ObjectID  `json:"updatedBy" bson:"updatedBy" validate:"required"`         // Reference to User ID
+	DeletedAt     *time.Time          `json:"deletedAt" bson:"deletedAt"`                             // Soft delete field
+	LastUpdatedBy primitive.ObjectID  `json:"lastUpdatedBy" bson:"lastUpdatedBy" validate:"required"` // Audit logging
+	Version       int64               `json:"version" bson:"version"`                                 // Incremented on every change, served as the ETag
+
+	Distance   *float64          `json:"distance,omitempty" bson:"distance,omitempty"` // Meters from the searched point, only set by geo searches
+	Score      *float64          `json:"score,omitempty" bson:"score,omitempty"`       // Text search relevance, only set by text searches
+	Highlights map[string]string `json:"highlights,omitempty" bson:"-"`                // Matched snippets by field, only set by text searches
+
+	DisplayPrice *DisplayPrice `json:"displayPrice,omitempty" bson:"-"` // Price in the currency asked by the client, only set when it asks for one
+}
+
+// DisplayPrice is the price of a rental converted to the currency a client displays prices in
+type DisplayPrice struct {
+	Amount   int64   `json:"amount"`
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"` // Value of one unit of the rental currency in Currency
+}
+
+// Point converts the string coordinates of the geometry into a GeoJSON point
+func (g Geometry) Point() (geo.Point, error) {
+	return geo.ParsePoint(g.Lat, g.Lng)
 }
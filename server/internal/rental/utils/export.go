@@ -0,0 +1,369 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/geo"
+	types "server/internal/rental/types"
+)
+
+// ErrUnknownExportFormat is returned for an export format other than csv, geojson or kml
+var ErrUnknownExportFormat = errors.New("unknown export format, use csv, geojson or kml")
+
+// ExportContentTypes maps the export formats to the content type of their documents
+var ExportContentTypes = map[string]string{
+	"csv":     "text/csv; charset=utf-8",
+	"geojson": "application/geo+json",
+	"kml":     "application/vnd.google-earth.kml+xml",
+}
+
+// RentalEncoder writes rentals one at a time as an export document
+type RentalEncoder interface {
+	Encode(rental types.Rental) error
+	// Close ends the document, it must be called once every rental is encoded
+	Close() error
+}
+
+// NewRentalEncoder returns an encoder writing to w in the given format
+func NewRentalEncoder(w io.Writer, format string) (RentalEncoder, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return &csvEncoder{writer: csv.NewWriter(w)}, nil
+	case "geojson":
+		return &geoJSONEncoder{w: w}, nil
+	case "kml":
+		return &kmlEncoder{w: w, encoder: xml.NewEncoder(w)}, nil
+	default:
+		return nil, ErrUnknownExportFormat
+	}
+}
+
+// exportColumns are the CSV columns of an export, in order. They are named like the columns imports read,
+// so that an export can be imported again, the columns managed by the server being ignored then.
+var exportColumns = []struct {
+	name  string
+	value func(r types.Rental) string
+}{
+	{"id", func(r types.Rental) string { return r.ID.Hex() }},
+	{"status", func(r types.Rental) string { return string(r.Status) }},
+	{"name", func(r types.Rental) string { return r.Name }},
+	{"description", func(r types.Rental) string { return r.Description }},
+	{"price", func(r types.Rental) string { return strconv.FormatInt(r.Price, 10) }},
+	{"currency", func(r types.Rental) string { return r.Currency }},
+	{"priceTND", func(r types.Rental) string { return formatOptionalInt(r.PriceTND) }},
+	{"bedrooms", func(r types.Rental) string { return strconv.FormatInt(r.Bedrooms, 10) }},
+	{"bathrooms", func(r types.Rental) string { return strconv.FormatInt(r.Bathrooms, 10) }},
+	{"areaSize", func(r types.Rental) string { return strconv.FormatInt(r.AreaSize, 10) }},
+	{"available", func(r types.Rental) string { return strconv.FormatBool(r.Available) }},
+	{"availableFrom", func(r types.Rental) string { return formatTime(&r.AvailableFrom) }},
+	{"agreeToTerms", func(r types.Rental) string { return strconv.FormatBool(r.AgreeToTerms) }},
+	{"type", func(r types.Rental) string { return string(r.Type) }},
+	{"standing", func(r types.Rental) string { return string(r.Standing) }},
+	{"tags", func(r types.Rental) string { return strings.Join(r.Tags, importListSeparator) }},
+	{"images", func(r types.Rental) string { return strings.Join(r.Images, importListSeparator) }},
+	{"address.streetNumber", func(r types.Rental) string { return r.Address.StreetNumber }},
+	{"address.street", func(r types.Rental) string { return r.Address.Street }},
+	{"address.city", func(r types.Rental) string { return r.Address.City }},
+	{"address.country", func(r types.Rental) string { return r.Address.Country }},
+	{"geometry.lat", func(r types.Rental) string { return r.Geometry.Lat }},
+	{"geometry.lng", func(r types.Rental) string { return r.Geometry.Lng }},
+	{"amenities.airConditioning", func(r types.Rental) string { return strconv.FormatBool(r.Amenities.AirConditioning) }},
+	{"amenities.heating", func(r types.Rental) string { return strconv.FormatBool(r.Amenities.Heating) }},
+	{"amenities.refrigerator", func(r types.Rental) string { return strconv.FormatBool(r.Amenities.Refrigerator) }},
+	{"amenities.parking", func(r types.Rental) string { return strconv.FormatBool(r.Amenities.Parking) }},
+	{"rules.petsAllowed", func(r types.Rental) string { return strconv.FormatBool(r.Rules.PetsAllowed) }},
+	{"rules.partiesAllowed", func(r types.Rental) string { return strconv.FormatBool(r.Rules.PartiesAllowed) }},
+	{"rules.smokingAllowed", func(r types.Rental) string { return strconv.FormatBool(r.Rules.SmokingAllowed) }},
+	{"shared.roomCount", func(r types.Rental) string {
+		if r.Shared == nil {
+			return ""
+		}
+		return strconv.FormatInt(r.Shared.RoomCount, 10)
+	}},
+	{"shared.occupants", func(r types.Rental) string {
+		if r.Shared == nil {
+			return ""
+		}
+		return strconv.FormatInt(r.Shared.Occupants, 10)
+	}},
+	{"shared.genderPreference", func(r types.Rental) string {
+		if r.Shared == nil {
+			return ""
+		}
+		return string(r.Shared.GenderPreference)
+	}},
+	{"sale.salePrice", func(r types.Rental) string {
+		if r.Sale == nil {
+			return ""
+		}
+		return strconv.FormatInt(r.Sale.SalePrice, 10)
+	}},
+	{"sale.titleStatus", func(r types.Rental) string {
+		if r.Sale == nil {
+			return ""
+		}
+		return string(r.Sale.TitleStatus)
+	}},
+	{"sale.negotiable", func(r types.Rental) string {
+		if r.Sale == nil {
+			return ""
+		}
+		return strconv.FormatBool(r.Sale.Negotiable)
+	}},
+	{"independent.leaseMonths", func(r types.Rental) string {
+		if r.Independent == nil {
+			return ""
+		}
+		return strconv.FormatInt(r.Independent.LeaseMonths, 10)
+	}},
+	{"independent.deposit", func(r types.Rental) string {
+		if r.Independent == nil {
+			return ""
+		}
+		return strconv.FormatInt(r.Independent.Deposit, 10)
+	}},
+	{"createdAt", func(r types.Rental) string { return formatTime(&r.CreatedAt) }},
+	{"expiresAt", func(r types.Rental) string { return formatTime(r.ExpiresAt) }},
+}
+
+type csvEncoder struct {
+	writer  *csv.Writer
+	started bool
+}
+
+func (e *csvEncoder) Encode(rental types.Rental) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	record := make([]string, len(exportColumns))
+	for i, column := range exportColumns {
+		record[i] = escapeFormula(column.value(rental))
+	}
+	return e.writer.Write(record)
+}
+
+// formulaPrefixes are the first characters that make spreadsheets read a cell as a formula,
+// and the quote that escapes them so that unescapeFormula can tell an escaped cell apart
+const formulaPrefixes = "=+-@'"
+
+// escapeFormula quotes a CSV cell that a spreadsheet would evaluate, e.g. a name like =HYPERLINK(...)
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// start writes the header line before the first row
+func (e *csvEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	header := make([]string, len(exportColumns))
+	for i, column := range exportColumns {
+		header[i] = column.name
+	}
+	return e.writer.Write(header)
+}
+
+// geoJSONEncoder writes a FeatureCollection, one feature per rental located by its coordinates
+type geoJSONEncoder struct {
+	w     io.Writer
+	count int
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	Geometry   *geo.Point        `json:"geometry"` // null for rentals without valid coordinates
+	Properties geoJSONProperties `json:"properties"`
+}
+
+type geoJSONProperties struct {
+	Name          string           `json:"name"`
+	Status        types.Status     `json:"status"`
+	Type          types.RentalType `json:"type"`
+	Standing      types.Standing   `json:"standing"`
+	Price         int64            `json:"price"`
+	Currency      string           `json:"currency"`
+	PriceTND      *int64           `json:"priceTND,omitempty"`
+	Bedrooms      int64            `json:"bedrooms"`
+	Bathrooms     int64            `json:"bathrooms"`
+	AreaSize      int64            `json:"areaSize"`
+	Available     bool             `json:"available"`
+	AvailableFrom time.Time        `json:"availableFrom"`
+	Address       string           `json:"address"`
+	Tags          []string         `json:"tags"`
+	Images        []string         `json:"images"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	ExpiresAt     *time.Time       `json:"expiresAt,omitempty"`
+}
+
+func (e *geoJSONEncoder) Encode(rental types.Rental) error {
+	separator := ","
+	if e.count == 0 {
+		separator = `{"type":"FeatureCollection","features":[`
+	}
+	e.count++
+
+	feature, err := json.Marshal(geoJSONFeature{
+		Type:     "Feature",
+		ID:       rental.ID.Hex(),
+		Geometry: rentalPoint(rental),
+		Properties: geoJSONProperties{
+			Name:          rental.Name,
+			Status:        rental.Status,
+			Type:          rental.Type,
+			Standing:      rental.Standing,
+			Price:         rental.Price,
+			Currency:      rental.Currency,
+			PriceTND:      rental.PriceTND,
+			Bedrooms:      rental.Bedrooms,
+			Bathrooms:     rental.Bathrooms,
+			AreaSize:      rental.AreaSize,
+			Available:     rental.Available,
+			AvailableFrom: rental.AvailableFrom,
+			Address:       rental.Address.FullAddress,
+			Tags:          rental.Tags,
+			Images:        rental.Images,
+			CreatedAt:     rental.CreatedAt,
+			ExpiresAt:     rental.ExpiresAt,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(feature)
+	return err
+}
+
+func (e *geoJSONEncoder) Close() error {
+	end := "]}"
+	if e.count == 0 {
+		end = `{"type":"FeatureCollection","features":[]}`
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// kmlEncoder writes a KML document, one placemark per rental
+type kmlEncoder struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	started bool
+}
+
+type kmlPlacemark struct {
+	XMLName     xml.Name  `xml:"Placemark"`
+	ID          string    `xml:"id,attr"`
+	Name        string    `xml:"name"`
+	Address     string    `xml:"address,omitempty"`
+	Description string    `xml:"description,omitempty"`
+	Data        []kmlData `xml:"ExtendedData>Data"`
+	Point       *kmlPoint `xml:"Point,omitempty"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"` // longitude,latitude
+}
+
+func (e *kmlEncoder) Encode(rental types.Rental) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	placemark := kmlPlacemark{
+		ID:          rental.ID.Hex(),
+		Name:        rental.Name,
+		Address:     rental.Address.FullAddress,
+		Description: rental.Description,
+		Data: []kmlData{
+			{"status", string(rental.Status)},
+			{"type", string(rental.Type)},
+			{"price", strconv.FormatInt(rental.Price, 10)},
+			{"currency", rental.Currency},
+			{"priceTND", formatOptionalInt(rental.PriceTND)},
+			{"bedrooms", strconv.FormatInt(rental.Bedrooms, 10)},
+			{"bathrooms", strconv.FormatInt(rental.Bathrooms, 10)},
+			{"areaSize", strconv.FormatInt(rental.AreaSize, 10)},
+			{"available", strconv.FormatBool(rental.Available)},
+			{"images", strings.Join(rental.Images, " ")},
+		},
+	}
+	if point := rentalPoint(rental); point != nil {
+		placemark.Point = &kmlPoint{Coordinates: fmt.Sprintf("%g,%g", point.Lng(), point.Lat())}
+	}
+	return e.encoder.Encode(placemark)
+}
+
+func (e *kmlEncoder) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	if err := e.encoder.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "</Document></kml>\n")
+	return err
+}
+
+// start writes the opening of the document before the first placemark
+func (e *kmlEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	_, err := io.WriteString(e.w, xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>Rentals</name>`)
+	return err
+}
+
+// rentalPoint returns the location of a rental, read from its coordinates for rentals stored before locations were
+func rentalPoint(rental types.Rental) *geo.Point {
+	if rental.Location != nil {
+		return rental.Location
+	}
+	point, err := geo.ParsePoint(rental.Geometry.Lat, rental.Geometry.Lng)
+	if err != nil {
+		return nil
+	}
+	return &point
+}
+
+func formatOptionalInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// snippetRadius is the number of characters kept on each side of the first match
+const snippetRadius = 60
+
+// SearchTerms extracts the words of a text search, dropping quotes and negated terms ("-word")
+func SearchTerms(search string) []string {
+	var terms []string
+	for _, word := range strings.Fields(strings.ReplaceAll(search, `"`, " ")) {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		if folded := foldString(word); folded != "" {
+			terms = append(terms, folded)
+		}
+	}
+	return terms
+}
+
+// Highlight wraps every occurrence of the terms in <mark> tags and trims the text around the first one.
+// Matching ignores case and diacritics, so "ecole" matches "École" and Arabic text matches with or without harakat.
+// The rest of the text is HTML escaped. The boolean is false when no term matches.
+func Highlight(text string, terms []string) (string, bool) {
+	runes := []rune(text)
+	folded, origin := foldRunes(runes)
+
+	// Mark the original runes covered by a match
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		needle := []rune(term)
+		for i := 0; i+len(needle) <= len(folded); i++ {
+			if !hasPrefix(folded[i:], needle) {
+				continue
+			}
+			start, end := origin[i], origin[i+len(needle)-1]
+			// Extend the match over trailing combining marks of the last character
+			for end+1 < len(runes) && unicode.Is(unicode.Mn, runes[end+1]) {
+				end++
+			}
+			for j := start; j <= end; j++ {
+				marked[j] = true
+			}
+			if first == -1 || start < first {
+				first = start
+			}
+		}
+	}
+	if first == -1 {
+		return "", false
+	}
+
+	from, to := first-snippetRadius, first+snippetRadius
+	if from < 0 {
+		from = 0
+	}
+	if to > len(runes) {
+		to = len(runes)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	for i := from; i < to; i++ {
+		if marked[i] && (i == from || !marked[i-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+		if marked[i] && (i == to-1 || !marked[i+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String(), true
+}
+
+// foldRunes lower-cases the runes and strips their diacritics.
+// origin maps every folded rune back to the index of the rune it comes from.
+func foldRunes(runes []rune) (folded []rune, origin []int) {
+	for i, r := range runes {
+		for _, base := range norm.NFD.String(string(r)) {
+			if unicode.Is(unicode.Mn, base) {
+				continue
+			}
+			folded = append(folded, unicode.ToLower(base))
+			origin = append(origin, i)
+		}
+	}
+	return folded, origin
+}
+
+func foldString(s string) string {
+	folded, _ := foldRunes([]rune(s))
+	return string(folded)
+}
+
+func hasPrefix(s, prefix []rune) bool {
+	if len(prefix) == 0 || len(s) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
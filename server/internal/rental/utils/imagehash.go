@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// ImageHash computes the average hash of an image: one bit per cell of an 8x8 grayscale thumbnail,
+// set when the cell is brighter than the mean. Resized or recompressed copies of a picture get the same hash
+// or one a few bits apart, see HashDistance.
+func ImageHash(img image.Image) uint64 {
+	thumbnail := imaging.Resize(imaging.Grayscale(img), 8, 8, imaging.Box)
+
+	var levels [64]uint32
+	var sum uint32
+	for i := range levels {
+		// Grayscale pixels have equal channels, red is enough
+		levels[i] = uint32(thumbnail.Pix[i*4])
+		sum += levels[i]
+	}
+	mean := sum / 64
+
+	var hash uint64
+	for i, level := range levels {
+		if level > mean {
+			hash |= 1 << uint(63-i)
+		}
+	}
+	return hash
+}
+
+// HashImageFile computes the average hash of a stored rental image, formatted as 16 hex digits
+func HashImageFile(path string) (string, error) {
+	file, err := os.Open(ImageFilePath(path))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return fmt.Sprintf("%016x", ImageHash(img)), nil
+}
+
+// HashDistance returns the number of bits two image hashes differ by, or -1 if one cannot be read
+func HashDistance(a, b string) int {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return -1
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return -1
+	}
+	return bits.OnesCount64(x ^ y)
+}
+
+// ImageFilePath returns the file of a stored image path, which lost the ../ of the asset folder when it was stored
+func ImageFilePath(path string) string {
+	if strings.HasPrefix(GetBasePath(), "../") && !strings.HasPrefix(path, "../") {
+		return "../" + path
+	}
+	return path
+}
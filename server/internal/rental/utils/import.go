@@ -0,0 +1,250 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	types "server/internal/rental/types"
+)
+
+// ErrInvalidImport is returned when an import file cannot be read at all, as opposed to some of its rows
+var ErrInvalidImport = errors.New("invalid import file")
+
+// importListSeparator separates the values of list columns, e.g. tags or images, in CSV files
+const importListSeparator = "|"
+
+// importColumns maps the CSV columns, named after the JSON paths of a rental, to the field they set.
+// Empty cells leave the field unset.
+var importColumns = map[string]func(rental *types.Rental, value string) error{
+	// Exports also carry fields managed by the server, they are ignored so that an export can be imported again
+	"id":        ignoreColumn,
+	"status":    ignoreColumn,
+	"priceTND":  ignoreColumn,
+	"createdAt": ignoreColumn,
+	"expiresAt": ignoreColumn,
+
+	"name":          func(r *types.Rental, v string) error { r.Name = v; return nil },
+	"description":   func(r *types.Rental, v string) error { r.Description = v; return nil },
+	"price":         intColumn(func(r *types.Rental) *int64 { return &r.Price }),
+	"currency":      func(r *types.Rental, v string) error { r.Currency = strings.ToUpper(v); return nil },
+	"bedrooms":      intColumn(func(r *types.Rental) *int64 { return &r.Bedrooms }),
+	"bathrooms":     intColumn(func(r *types.Rental) *int64 { return &r.Bathrooms }),
+	"areaSize":      intColumn(func(r *types.Rental) *int64 { return &r.AreaSize }),
+	"available":     boolColumn(func(r *types.Rental) *bool { return &r.Available }),
+	"availableFrom": availableFromColumn,
+	"agreeToTerms":  boolColumn(func(r *types.Rental) *bool { return &r.AgreeToTerms }),
+	"type":          func(r *types.Rental, v string) error { r.Type = types.RentalType(v); return nil },
+	"standing":      func(r *types.Rental, v string) error { r.Standing = types.Standing(v); return nil },
+	"tags":          func(r *types.Rental, v string) error { r.Tags = splitList(v); return nil },
+	"images":        func(r *types.Rental, v string) error { r.Images = splitList(v); return nil },
+
+	"address.streetNumber": func(r *types.Rental, v string) error { r.Address.StreetNumber = v; return nil },
+	"address.street":       func(r *types.Rental, v string) error { r.Address.Street = v; return nil },
+	"address.city":         func(r *types.Rental, v string) error { r.Address.City = v; return nil },
+	"address.country":      func(r *types.Rental, v string) error { r.Address.Country = v; return nil },
+	"geometry.lat":         func(r *types.Rental, v string) error { r.Geometry.Lat = v; return nil },
+	"geometry.lng":         func(r *types.Rental, v string) error { r.Geometry.Lng = v; return nil },
+
+	"amenities.airConditioning": boolColumn(func(r *types.Rental) *bool { return &r.Amenities.AirConditioning }),
+	"amenities.heating":         boolColumn(func(r *types.Rental) *bool { return &r.Amenities.Heating }),
+	"amenities.refrigerator":    boolColumn(func(r *types.Rental) *bool { return &r.Amenities.Refrigerator }),
+	"amenities.parking":         boolColumn(func(r *types.Rental) *bool { return &r.Amenities.Parking }),
+	"rules.petsAllowed":         boolColumn(func(r *types.Rental) *bool { return &r.Rules.PetsAllowed }),
+	"rules.partiesAllowed":      boolColumn(func(r *types.Rental) *bool { return &r.Rules.PartiesAllowed }),
+	"rules.smokingAllowed":      boolColumn(func(r *types.Rental) *bool { return &r.Rules.SmokingAllowed }),
+
+	"shared.roomCount":        intColumn(func(r *types.Rental) *int64 { return &shared(r).RoomCount }),
+	"shared.occupants":        intColumn(func(r *types.Rental) *int64 { return &shared(r).Occupants }),
+	"shared.genderPreference": func(r *types.Rental, v string) error { shared(r).GenderPreference = types.Gender(v); return nil },
+	"sale.salePrice":          intColumn(func(r *types.Rental) *int64 { return &sale(r).SalePrice }),
+	"sale.titleStatus":        func(r *types.Rental, v string) error { sale(r).TitleStatus = types.TitleStatus(v); return nil },
+	"sale.negotiable":         boolColumn(func(r *types.Rental) *bool { return &sale(r).Negotiable }),
+	"independent.leaseMonths": intColumn(func(r *types.Rental) *int64 { return &independent(r).LeaseMonths }),
+	"independent.deposit":     intColumn(func(r *types.Rental) *int64 { return &independent(r).Deposit }),
+}
+
+// ParseImport reads the rentals of a CSV file with a header line, or of a JSON Lines file with one rental per line.
+// Rows that cannot be read are returned with their error, the file as a whole only fails on an unknown format or column.
+func ParseImport(src io.Reader, format string) ([]types.ImportRow, error) {
+	var rows []types.ImportRow
+	var err error
+	switch strings.ToLower(format) {
+	case "csv":
+		rows, err = parseCSVImport(src)
+	case "jsonl", "ndjson":
+		rows, err = parseJSONLinesImport(src)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q, use csv or jsonl", ErrInvalidImport, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no rentals found", ErrInvalidImport)
+	}
+	if len(rows) > types.MaxImportRows {
+		return nil, fmt.Errorf("%w: %d rentals, at most %d can be imported at once", ErrInvalidImport, len(rows), types.MaxImportRows)
+	}
+	return rows, nil
+}
+
+func parseCSVImport(src io.Reader) ([]types.ImportRow, error) {
+	reader := csv.NewReader(src)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read the header line: %v", ErrInvalidImport, err)
+	}
+	setters := make([]func(*types.Rental, string) error, len(header))
+	for i, column := range header {
+		setter, ok := importColumns[strings.TrimSpace(column)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidImport, column)
+		}
+		setters[i] = setter
+	}
+
+	var rows []types.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, types.ImportRow{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := types.ImportRow{Line: line}
+		for i, value := range record {
+			value = unescapeFormula(strings.TrimSpace(value))
+			if value == "" {
+				continue
+			}
+			if err := setters[i](&row.Rental, value); err != nil {
+				row.Err = fmt.Errorf("%s: %w", strings.TrimSpace(header[i]), err)
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseJSONLinesImport(src io.Reader) ([]types.ImportRow, error) {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []types.ImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := types.ImportRow{Line: line}
+		if err := json.Unmarshal([]byte(text), &row.Rental); err != nil {
+			row.Rental = types.Rental{}
+			row.Err = fmt.Errorf("invalid JSON: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return rows, nil
+}
+
+func ignoreColumn(*types.Rental, string) error {
+	return nil
+}
+
+func intColumn(field func(*types.Rental) *int64) func(*types.Rental, string) error {
+	return func(r *types.Rental, v string) error {
+		value, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		*field(r) = value
+		return nil
+	}
+}
+
+func boolColumn(field func(*types.Rental) *bool) func(*types.Rental, string) error {
+	return func(r *types.Rental, v string) error {
+		value, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		*field(r) = value
+		return nil
+	}
+}
+
+// availableFromColumn reads a date (YYYY-MM-DD) or an RFC 3339 time
+func availableFromColumn(r *types.Rental, v string) error {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			r.AvailableFrom = t
+			return nil
+		}
+	}
+	return errors.New("must be a date, e.g. 2024-06-01")
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, importListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// The details of a type are only allocated when one of their columns is filled in
+
+func shared(r *types.Rental) *types.SharedDetails {
+	if r.Shared == nil {
+		r.Shared = &types.SharedDetails{}
+	}
+	return r.Shared
+}
+
+func sale(r *types.Rental) *types.SaleDetails {
+	if r.Sale == nil {
+		r.Sale = &types.SaleDetails{}
+	}
+	return r.Sale
+}
+
+func independent(r *types.Rental) *types.IndependentDetails {
+	if r.Independent == nil {
+		r.Independent = &types.IndependentDetails{}
+	}
+	return r.Independent
+}
+
+// unescapeFormula removes the quote added by escapeFormula, so that exported files import back as they were
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
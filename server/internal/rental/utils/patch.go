@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strings"
+
+	"server/internal/jsonpatch"
+	types "server/internal/rental/types"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// ErrUnsupportedPatch is returned when the content type of a patch is neither a merge patch nor a JSON Patch
+var ErrUnsupportedPatch = errors.New("unsupported patch format, use " + MergePatchType + " or " + JSONPatchType)
+
+// patchableFields are the members of a rental its owner can patch.
+// The others are managed by the server: identity, moderation, bookkeeping and images, which go through uploads.
+var patchableFields = map[string]bool{
+	"name":          true,
+	"address":       true,
+	"geometry":      true,
+	"agreeToTerms":  true,
+	"description":   true,
+	"price":         true,
+	"currency":      true,
+	"bedrooms":      true,
+	"bathrooms":     true,
+	"areaSize":      true,
+	"available":     true,
+	"availableFrom": true,
+	"tags":          true,
+	"type":          true,
+	"shared":        true,
+	"sale":          true,
+	"independent":   true,
+	"standing":      true,
+	"amenities":     true,
+	"rules":         true,
+}
+
+// PatchRental applies a merge patch or a JSON Patch, depending on the content type, to a rental.
+// It returns the patched rental along with the struct fields the patch touched, for partial validation.
+// Plain application/json is read as a merge patch.
+func PatchRental(rental types.Rental, contentType string, patch []byte) (*types.Rental, []string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, nil, ErrUnsupportedPatch
+	}
+
+	doc, err := json.Marshal(rental)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var paths [][]string
+	var patched []byte
+	switch mediaType {
+	case MergePatchType, "application/json":
+		if paths, err = jsonpatch.MergePatchPaths(patch); err != nil {
+			return nil, nil, err
+		}
+		if err := checkPatchable(paths); err != nil {
+			return nil, nil, err
+		}
+		patched, err = jsonpatch.MergePatch(doc, patch)
+	case JSONPatchType:
+		var ops []jsonpatch.Operation
+		if ops, err = jsonpatch.DecodePatch(patch); err != nil {
+			return nil, nil, err
+		}
+		for _, op := range ops {
+			path, _ := jsonpatch.ParsePointer(op.Path)
+			if op.Op != "test" {
+				paths = append(paths, path)
+			}
+			if op.Op == "move" {
+				from, _ := jsonpatch.ParsePointer(op.From)
+				paths = append(paths, from)
+			}
+		}
+		if err := checkPatchable(paths); err != nil {
+			return nil, nil, err
+		}
+		patched, err = jsonpatch.Apply(doc, ops)
+	default:
+		return nil, nil, ErrUnsupportedPatch
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result types.Rental
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", jsonpatch.ErrInvalidPatch, err)
+	}
+
+	var fields []string
+	for _, path := range paths {
+		fields = append(fields, structFields(reflect.TypeOf(result), path, "")...)
+	}
+	return &result, fields, nil
+}
+
+// checkPatchable rejects patches that touch a member managed by the server
+func checkPatchable(paths [][]string) error {
+	for _, path := range paths {
+		if len(path) == 0 {
+			return fmt.Errorf("%w: the whole rental cannot be replaced", jsonpatch.ErrInvalidPatch)
+		}
+		if !patchableFields[path[0]] || (path[0] == "address" && len(path) > 1 && path[1] == "fullAddress") {
+			return fmt.Errorf("%w: %s cannot be patched", jsonpatch.ErrInvalidPatch, strings.Join(path, "."))
+		}
+	}
+	return nil
+}
+
+// RentalFields returns the struct fields under the given JSON members of a rental, for StructPartial
+func RentalFields(members ...string) []string {
+	var fields []string
+	for _, member := range members {
+		fields = append(fields, structFields(reflect.TypeOf(types.Rental{}), []string{member}, "")...)
+	}
+	return fields
+}
+
+// structFields maps a JSON path to the names of the struct fields it covers, as used by StructPartial.
+// A path ending on a struct covers all of its fields, a path into a slice covers the whole slice.
+func structFields(t reflect.Type, path []string, prefix string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.String() == "time.Time" {
+		return []string{strings.TrimSuffix(prefix, ".")}
+	}
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if len(path) == 0 {
+			fields = append(fields, structFields(field.Type, nil, prefix+field.Name+".")...)
+		} else if name == path[0] {
+			return structFields(field.Type, path[1:], prefix+field.Name+".")
+		}
+	}
+	return fields
+}
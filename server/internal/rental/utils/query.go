@@ -0,0 +1,375 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	currencyTypes "server/internal/currency/types"
+	"server/internal/geo"
+	types "server/internal/rental/types"
+)
+
+// ParseRentalQuery converts the query parameters of a rental search into a RentalQuery.
+// List parameters (standing, type, gender, titleStatus, tags) accept comma separated values or repeated keys.
+func ParseRentalQuery(values url.Values) (types.RentalQuery, error) {
+	var query types.RentalQuery
+	var err error
+
+	query.Text = strings.TrimSpace(values.Get("q"))
+
+	if raw := values.Get("currency"); raw != "" {
+		query.Currency = strings.ToUpper(raw)
+		if !currencyTypes.IsSupported(query.Currency) {
+			return query, fmt.Errorf("invalid currency: %s", raw)
+		}
+	}
+
+	// Numeric ranges
+	ranges := []struct {
+		key string
+		dst **int64
+	}{
+		{"minPrice", &query.MinPrice},
+		{"maxPrice", &query.MaxPrice},
+		{"minBedrooms", &query.MinBedrooms},
+		{"maxBedrooms", &query.MaxBedrooms},
+		{"minBathrooms", &query.MinBathrooms},
+		{"maxBathrooms", &query.MaxBathrooms},
+		{"minAreaSize", &query.MinAreaSize},
+		{"maxAreaSize", &query.MaxAreaSize},
+		{"minRoomCount", &query.Shared.MinRoomCount},
+		{"maxRoomCount", &query.Shared.MaxRoomCount},
+		{"maxOccupants", &query.Shared.MaxOccupants},
+		{"minSalePrice", &query.Sale.MinSalePrice},
+		{"maxSalePrice", &query.Sale.MaxSalePrice},
+		{"minLeaseMonths", &query.Independent.MinLeaseMonths},
+		{"maxLeaseMonths", &query.Independent.MaxLeaseMonths},
+		{"maxDeposit", &query.Independent.MaxDeposit},
+	}
+	for _, r := range ranges {
+		if *r.dst, err = parseInt64Param(values, r.key); err != nil {
+			return query, err
+		}
+	}
+	if err := checkRange("price", query.MinPrice, query.MaxPrice); err != nil {
+		return query, err
+	}
+	if err := checkRange("bedrooms", query.MinBedrooms, query.MaxBedrooms); err != nil {
+		return query, err
+	}
+	if err := checkRange("bathrooms", query.MinBathrooms, query.MaxBathrooms); err != nil {
+		return query, err
+	}
+	if err := checkRange("areaSize", query.MinAreaSize, query.MaxAreaSize); err != nil {
+		return query, err
+	}
+	if err := checkRange("roomCount", query.Shared.MinRoomCount, query.Shared.MaxRoomCount); err != nil {
+		return query, err
+	}
+	if err := checkRange("salePrice", query.Sale.MinSalePrice, query.Sale.MaxSalePrice); err != nil {
+		return query, err
+	}
+	if err := checkRange("leaseMonths", query.Independent.MinLeaseMonths, query.Independent.MaxLeaseMonths); err != nil {
+		return query, err
+	}
+
+	// Enumerations
+	for _, s := range parseListParam(values, "standing") {
+		switch standing := types.Standing(s); standing {
+		case types.Economy, types.Standard, types.Luxury:
+			query.Standing = append(query.Standing, standing)
+		default:
+			return query, fmt.Errorf("invalid standing: %s", s)
+		}
+	}
+	for _, t := range parseListParam(values, "type") {
+		switch rentalType := types.RentalType(t); rentalType {
+		case types.Shared, types.Independent, types.Sale:
+			query.Type = append(query.Type, rentalType)
+		default:
+			return query, fmt.Errorf("invalid rental type: %s", t)
+		}
+	}
+	for _, g := range parseListParam(values, "gender") {
+		switch gender := types.Gender(g); gender {
+		case types.AnyGender, types.Male, types.Female:
+			query.Shared.GenderPreference = append(query.Shared.GenderPreference, gender)
+		default:
+			return query, fmt.Errorf("invalid gender preference: %s", g)
+		}
+	}
+	for _, t := range parseListParam(values, "titleStatus") {
+		switch status := types.TitleStatus(t); status {
+		case types.Registered, types.InProgress, types.Unregistered:
+			query.Sale.TitleStatus = append(query.Sale.TitleStatus, status)
+		default:
+			return query, fmt.Errorf("invalid title status: %s", t)
+		}
+	}
+	query.Tags = parseListParam(values, "tags")
+
+	// Flags
+	flags := []struct {
+		key string
+		dst **bool
+	}{
+		{"available", &query.Available},
+		{"airConditioning", &query.Amenities.AirConditioning},
+		{"heating", &query.Amenities.Heating},
+		{"refrigerator", &query.Amenities.Refrigerator},
+		{"parking", &query.Amenities.Parking},
+		{"petsAllowed", &query.Rules.PetsAllowed},
+		{"partiesAllowed", &query.Rules.PartiesAllowed},
+		{"smokingAllowed", &query.Rules.SmokingAllowed},
+		{"negotiable", &query.Sale.Negotiable},
+	}
+	for _, f := range flags {
+		if *f.dst, err = parseBoolParam(values, f.key); err != nil {
+			return query, err
+		}
+	}
+
+	if query.AvailableFrom, err = parseDateParam(values, "availableFrom"); err != nil {
+		return query, err
+	}
+	if query.AvailableBetween, err = parseDateRange(values, "availableStart", "availableEnd"); err != nil {
+		return query, err
+	}
+
+	if query.Near, err = parseNearParams(values); err != nil {
+		return query, err
+	}
+	if raw := values.Get("bbox"); raw != "" {
+		box, err := geo.ParseBoundingBox(raw)
+		if err != nil {
+			return query, err
+		}
+		query.Within = &box
+	}
+
+	return query, nil
+}
+
+// parseNearParams reads the lat, lng and radius (meters) parameters of a search around a point
+func parseNearParams(values url.Values) (*types.NearQuery, error) {
+	lat, lng := values.Get("lat"), values.Get("lng")
+	if lat == "" && lng == "" {
+		return nil, nil
+	}
+	if lat == "" || lng == "" {
+		return nil, fmt.Errorf("lat and lng must be provided together")
+	}
+	point, err := geo.ParsePoint(lat, lng)
+	if err != nil {
+		return nil, err
+	}
+
+	radius := types.DefaultNearRadius
+	if raw := values.Get("radius"); raw != "" {
+		radius, err = strconv.ParseFloat(raw, 64)
+		if err != nil || radius <= 0 {
+			return nil, fmt.Errorf("invalid radius: must be a positive number of meters")
+		}
+		radius = math.Min(radius, types.MaxNearRadius)
+	}
+
+	return &types.NearQuery{Point: point, Radius: radius}, nil
+}
+
+func parseInt64Param(values url.Values, key string) (*int64, error) {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid %s: must be a positive integer", key)
+	}
+	return &value, nil
+}
+
+func parseBoolParam(values url.Values, key string) (*bool, error) {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: must be true or false", key)
+	}
+	return &value, nil
+}
+
+// parseDateParam accepts either a plain date (2006-01-02) or an RFC 3339 timestamp
+func parseDateParam(values url.Values, key string) (*time.Time, error) {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	if date, err := time.Parse("2006-01-02", raw); err == nil {
+		return &date, nil
+	}
+	date, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected YYYY-MM-DD or RFC 3339", key)
+	}
+	return &date, nil
+}
+
+// parseDateRange reads a period of whole days from two date parameters, which go together
+func parseDateRange(values url.Values, startKey, endKey string) (*types.DateRange, error) {
+	start, err := parseDateParam(values, startKey)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDateParam(values, endKey)
+	if err != nil {
+		return nil, err
+	}
+	if start == nil && end == nil {
+		return nil, nil
+	}
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("%s and %s must be given together", startKey, endKey)
+	}
+
+	period := types.DateRange{Start: types.Day(*start), End: types.Day(*end)}
+	if !period.End.After(period.Start) {
+		return nil, fmt.Errorf("%s must be after %s", endKey, startKey)
+	}
+	return &period, nil
+}
+
+func parseListParam(values url.Values, key string) []string {
+	var list []string
+	for _, raw := range values[key] {
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func checkRange(name string, min, max *int64) error {
+	if min != nil && max != nil && *min > *max {
+		return fmt.Errorf("invalid %s range: min is greater than max", name)
+	}
+	return nil
+}
+
+// ParsePageRequest reads the limit, cursor, sort and total query parameters of a listing that is not a search.
+// A leading "-" on the sort field (e.g. sort=-price) orders the results descending.
+// Sorting by distance or relevance needs a search, see ParseSearch.
+func ParsePageRequest(values url.Values) (types.PageRequest, error) {
+	page, err := parsePageRequest(values)
+	if err != nil {
+		return page, err
+	}
+	if page.Sort == types.SortByDistance || page.Sort == types.SortByRelevance {
+		return page, fmt.Errorf("sorting by %s requires a search", page.Sort)
+	}
+	return page, nil
+}
+
+// parsePageRequest reads the pagination parameters, search only sorts included
+func parsePageRequest(values url.Values) (types.PageRequest, error) {
+	page := types.PageRequest{
+		Limit:      types.DefaultPageLimit,
+		Cursor:     values.Get("cursor"),
+		Sort:       types.SortByCreatedAt,
+		Descending: true,
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit < 1 {
+			return page, fmt.Errorf("invalid limit: must be a positive integer")
+		}
+		if limit > types.MaxPageLimit {
+			limit = types.MaxPageLimit
+		}
+		page.Limit = limit
+	}
+
+	if raw := values.Get("sort"); raw != "" {
+		page.Descending = strings.HasPrefix(raw, "-")
+		switch field := types.SortField(strings.TrimPrefix(raw, "-")); field {
+		case types.SortByPrice, types.SortByCreatedAt, types.SortByAreaSize, types.SortByDistance:
+			page.Sort = field
+		case types.SortByRelevance:
+			page.Sort, page.Descending = field, true
+		default:
+			return page, fmt.Errorf("invalid sort field: %s", field)
+		}
+	}
+
+	if raw := values.Get("total"); raw != "" {
+		includeTotal, err := strconv.ParseBool(raw)
+		if err != nil {
+			return page, fmt.Errorf("invalid total: must be true or false")
+		}
+		page.IncludeTotal = includeTotal
+	}
+
+	return page, nil
+}
+
+// ParseSearch reads both the filters and the pagination of a rental search
+func ParseSearch(values url.Values) (types.RentalQuery, types.PageRequest, error) {
+	query, err := ParseRentalQuery(values)
+	if err != nil {
+		return query, types.PageRequest{}, err
+	}
+	// Text searches are ranked by relevance unless the client asks for another order
+	if query.Text != "" && values.Get("sort") == "" {
+		values = cloneValues(values)
+		values.Set("sort", string(types.SortByRelevance))
+	}
+	page, err := parsePageRequest(values)
+	if err != nil {
+		return query, page, err
+	}
+	if page.Sort == types.SortByDistance && query.Near == nil {
+		return query, page, fmt.Errorf("sorting by distance requires lat and lng")
+	}
+	if page.Sort == types.SortByDistance && query.Text != "" {
+		return query, page, fmt.Errorf("text searches cannot be sorted by distance")
+	}
+	if page.Sort == types.SortByRelevance && query.Text == "" {
+		return query, page, fmt.Errorf("sorting by relevance requires q")
+	}
+	return query, page, nil
+}
+
+func cloneValues(values url.Values) url.Values {
+	clone := make(url.Values, len(values))
+	for key, value := range values {
+		clone[key] = append([]string(nil), value...)
+	}
+	return clone
+}
+
+// NormalisePriceRange converts the price bounds of a query from its currency to TND, the currency of the prices searches compare.
+// toBase converts an amount of a currency to TND.
+func NormalisePriceRange(query *types.RentalQuery, toBase func(amount int64, currency string) (int64, error)) error {
+	if query.Currency == "" || query.Currency == currencyTypes.BaseCurrency {
+		return nil
+	}
+	for _, bound := range []*int64{query.MinPrice, query.MaxPrice} {
+		if bound == nil {
+			continue
+		}
+		converted, err := toBase(*bound, query.Currency)
+		if err != nil {
+			return err
+		}
+		*bound = converted
+	}
+	return nil
+}
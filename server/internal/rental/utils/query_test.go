@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+
+	types "server/internal/rental/types"
+)
+
+func TestParsePageRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  types.PageRequest
+		err   string
+	}{
+		{
+			name:  "defaults to newest first",
+			query: "",
+			want:  types.PageRequest{Limit: types.DefaultPageLimit, Sort: types.SortByCreatedAt, Descending: true},
+		},
+		{
+			name:  "ascending sort with cursor and total",
+			query: "sort=price&limit=5&cursor=abc&total=true",
+			want:  types.PageRequest{Limit: 5, Cursor: "abc", Sort: types.SortByPrice, IncludeTotal: true},
+		},
+		{
+			name:  "leading dash sorts descending",
+			query: "sort=-areaSize",
+			want:  types.PageRequest{Limit: types.DefaultPageLimit, Sort: types.SortByAreaSize, Descending: true},
+		},
+		{
+			name:  "limit is capped",
+			query: "limit=1000",
+			want:  types.PageRequest{Limit: types.MaxPageLimit, Sort: types.SortByCreatedAt, Descending: true},
+		},
+		{name: "zero limit", query: "limit=0", err: "invalid limit: must be a positive integer"},
+		{name: "negative limit", query: "limit=-3", err: "invalid limit: must be a positive integer"},
+		{name: "non numeric limit", query: "limit=ten", err: "invalid limit: must be a positive integer"},
+		{name: "unknown sort field", query: "sort=-name", err: "invalid sort field: name"},
+		{name: "invalid total", query: "total=maybe", err: "invalid total: must be true or false"},
+		{name: "distance needs a search", query: "sort=distance", err: "sorting by distance requires a search"},
+		{name: "relevance needs a search", query: "sort=relevance", err: "sorting by relevance requires a search"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := url.ParseQuery(test.query)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			got, err := ParsePageRequest(values)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("ParsePageRequest error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePageRequest: %v", err)
+			}
+			if got != test.want {
+				t.Fatalf("ParsePageRequest = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		sort       types.SortField
+		descending bool
+		err        string
+	}{
+		{name: "text search ranked by relevance", query: "q=studio", sort: types.SortByRelevance, descending: true},
+		{name: "relevance is always best match first", query: "q=studio&sort=relevance", sort: types.SortByRelevance, descending: true},
+		{name: "text search with another order", query: "q=studio&sort=price", sort: types.SortByPrice},
+		{name: "distance around a point", query: "lat=36.8&lng=10.18&sort=distance", sort: types.SortByDistance},
+		{name: "distance without a point", query: "sort=distance", err: "sorting by distance requires lat and lng"},
+		{name: "distance of a text search", query: "q=studio&lat=36.8&lng=10.18&sort=-distance", err: "text searches cannot be sorted by distance"},
+		{name: "relevance without text", query: "sort=relevance", err: "sorting by relevance requires q"},
+		{name: "query errors come first", query: "minPrice=-1&limit=0", err: "invalid minPrice: must be a positive integer"},
+		{name: "page errors", query: "q=studio&limit=0", err: "invalid limit: must be a positive integer"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := url.ParseQuery(test.query)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			_, page, err := ParseSearch(values)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("ParseSearch error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSearch: %v", err)
+			}
+			if page.Sort != test.sort || page.Descending != test.descending {
+				t.Fatalf("ParseSearch sort = %s descending=%v, want %s descending=%v", page.Sort, page.Descending, test.sort, test.descending)
+			}
+		})
+	}
+}
+
+func TestParseRentalQueryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{name: "negative number", query: "minBedrooms=-1", err: "invalid minBedrooms: must be a positive integer"},
+		{name: "non numeric number", query: "maxPrice=cheap", err: "invalid maxPrice: must be a positive integer"},
+		{name: "inverted range", query: "minPrice=500&maxPrice=100", err: "invalid price range: min is greater than max"},
+		{name: "unsupported currency", query: "currency=gbp", err: "invalid currency: gbp"},
+		{name: "unknown standing", query: "standing=economy,palace", err: "invalid standing: palace"},
+		{name: "unknown type", query: "type=castle", err: "invalid rental type: castle"},
+		{name: "invalid flag", query: "parking=sometimes", err: "invalid parking: must be true or false"},
+		{name: "invalid date", query: "availableFrom=tomorrow", err: "invalid availableFrom: expected YYYY-MM-DD or RFC 3339"},
+		{name: "half a period", query: "availableStart=2024-07-01", err: "availableStart and availableEnd must be given together"},
+		{name: "empty period", query: "availableStart=2024-07-01&availableEnd=2024-07-01", err: "availableEnd must be after availableStart"},
+		{name: "latitude alone", query: "lat=36.8", err: "lat and lng must be provided together"},
+		{name: "invalid radius", query: "lat=36.8&lng=10.18&radius=0", err: "invalid radius: must be a positive number of meters"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := url.ParseQuery(test.query)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			if _, err := ParseRentalQuery(values); err == nil || err.Error() != test.err {
+				t.Fatalf("ParseRentalQuery error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StagedImageLifetime is how long an upload stays in the staging folder without being claimed by a rental
+const StagedImageLifetime = 24 * time.Hour
+
+// ErrUnknownImage is returned when an image reference does not match a staged upload of the user
+var ErrUnknownImage = errors.New("unknown image reference")
+
+// imageReference is the shape of the references handed out by StageImage
+var imageReference = regexp.MustCompile(`^[0-9a-f]{24}\.(jpg|png)$`)
+
+// stagingFolder holds the images a user uploaded for rentals that are not created yet
+func stagingFolder(userID primitive.ObjectID) string {
+	return filepath.Join(GetBasePath(), "staging", userID.Hex())
+}
+
+// StageImage resizes an uploaded image into the staging folder of the user and returns its reference.
+// The reference is then listed in the images of the rental JSON body, which claims the image.
+func StageImage(file *multipart.FileHeader, userID primitive.ObjectID) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	// Sniff the format first, the reference carries the extension
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return "", err
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image %s: %w", file.Filename, err)
+	}
+	extension := map[string]string{"jpeg": "jpg", "png": "png"}[format]
+	if extension == "" {
+		return "", fmt.Errorf("unsupported image format: %s", format)
+	}
+
+	folder := stagingFolder(userID)
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	reference := primitive.NewObjectID().Hex() + "." + extension
+	if err := ResizeImage(bytes.NewReader(data), filepath.Join(folder, reference)); err != nil {
+		return "", err
+	}
+	return reference, nil
+}
+
+// ClaimStagedImages moves the staged images of the user into the image folder of a rental,
+// and returns their paths in the form stored on rentals. Nothing is moved if any reference is unknown.
+func ClaimStagedImages(references []string, userID primitive.ObjectID, rentalFolder string) ([]string, error) {
+	folder := stagingFolder(userID)
+	seen := map[string]bool{}
+	for _, reference := range references {
+		if !imageReference.MatchString(reference) || seen[reference] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownImage, reference)
+		}
+		seen[reference] = true
+		if _, err := os.Stat(filepath.Join(folder, reference)); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownImage, reference)
+		}
+	}
+
+	if err := os.MkdirAll(rentalFolder, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create directory for rental images: %w", err)
+	}
+
+	var paths []string
+	for _, reference := range references {
+		dstPath := filepath.Join(rentalFolder, reference)
+		if err := os.Rename(filepath.Join(folder, reference), dstPath); err != nil {
+			ReleaseClaimedImages(paths, userID)
+			return nil, fmt.Errorf("failed to move image %s: %w", reference, err)
+		}
+		paths = append(paths, strings.TrimPrefix(dstPath, "../"))
+	}
+	return paths, nil
+}
+
+// ReleaseClaimedImages moves images claimed by a rental that could not be saved back to the staging folder of the user,
+// so that the same references can be sent again. Their lifetime in the staging folder starts over.
+func ReleaseClaimedImages(paths []string, userID primitive.ObjectID) {
+	folder := stagingFolder(userID)
+	now := time.Now()
+	for _, path := range paths {
+		stagedPath := filepath.Join(folder, filepath.Base(path))
+		if err := os.Rename(ImageFilePath(path), stagedPath); err != nil {
+			log.Printf("Failed to move image %s back to staging: %v", path, err)
+			continue
+		}
+		os.Chtimes(stagedPath, now, now)
+	}
+}
+
+// ImagePathFromURL returns the stored path of an image uploaded to this server, given the public URL
+// written by MapImagePathsToURLs, e.g. in an export. The host is not checked, the server may be reached under
+// several names, but the image must still exist. ok is false for any other URL.
+func ImagePathFromURL(imageURL string) (string, bool) {
+	parsed, err := url.Parse(imageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", false
+	}
+
+	prefix := strings.TrimPrefix(GetBasePath(), "../") + "/"
+	stored := parsed.Path
+	if !strings.HasPrefix(prefix, "/") {
+		stored = strings.TrimPrefix(stored, "/")
+	}
+	if !strings.HasPrefix(stored, prefix) || path.Clean(stored) != stored || strings.HasPrefix(stored, prefix+"staging/") {
+		return "", false
+	}
+
+	info, err := os.Stat(ImageFilePath(stored))
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return stored, true
+}
+
+// CopyImages copies uploaded images into the image folder of a rental, and returns their paths in the form stored on rentals.
+// Rentals never share an image file, which goes away with the rental it belongs to.
+func CopyImages(paths []string, rentalFolder string) ([]string, error) {
+	if err := os.MkdirAll(rentalFolder, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create directory for rental images: %w", err)
+	}
+
+	var copies []string
+	for _, imagePath := range paths {
+		dstPath := filepath.Join(rentalFolder, primitive.NewObjectID().Hex()+filepath.Ext(imagePath))
+		if err := copyFile(ImageFilePath(imagePath), dstPath); err != nil {
+			return nil, fmt.Errorf("failed to copy image %s: %w", imagePath, err)
+		}
+		copies = append(copies, strings.TrimPrefix(dstPath, "../"))
+	}
+	return copies, nil
+}
+
+func copyFile(srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// PurgeStagedImages removes the uploads left in the staging folders for longer than olderThan,
+// as well as the folders left empty, and returns how many images were removed
+func PurgeStagedImages(olderThan time.Duration) (int, error) {
+	root := filepath.Join(GetBasePath(), "staging")
+	users, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	for _, user := range users {
+		if !user.IsDir() {
+			continue
+		}
+		folder := filepath.Join(root, user.Name())
+		images, err := os.ReadDir(folder)
+		if err != nil {
+			log.Printf("Failed to read staging folder %s: %v", folder, err)
+			continue
+		}
+
+		left := len(images)
+		for _, image := range images {
+			info, err := image.Info()
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(folder, image.Name())); err != nil {
+				log.Printf("Failed to remove staged image %s: %v", image.Name(), err)
+				continue
+			}
+			removed++
+			left--
+		}
+		if left == 0 {
+			os.Remove(folder)
+		}
+	}
+	return removed, nil
+}
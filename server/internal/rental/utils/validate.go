@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	types "server/internal/rental/types"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns a validator for the rental struct tags and the checks spanning several fields.
+// Errors are reported with the JSON names clients send.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	validate.RegisterStructValidation(types.ValidateTypeDetails, types.Rental{})
+	return validate
+}
+
+// ValidationDetails maps the JSON path of each invalid field to the rule it breaks, e.g. address.city: required
+func ValidationDetails(err error) (map[string]string, bool) {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return nil, false
+	}
+	details := map[string]string{}
+	for _, e := range fieldErrors {
+		// Drop the leading struct name: Rental.address.city becomes address.city
+		field := e.Namespace()[strings.Index(e.Namespace(), ".")+1:]
+		details[field] = e.Tag()
+	}
+	return details, true
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"server/config"
+	rentalTypes "server/internal/rental/types"
+	"server/internal/reservation/service"
+	"server/internal/reservation/types"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type ReservationHandler struct {
+	service service.ReservationService
+}
+
+func NewReservationHandler(service service.ReservationService) *ReservationHandler {
+	return &ReservationHandler{service: service}
+}
+
+// RequestReservation handles the POST request of a tenant asking to book a rental.
+// The body carries the rental and the arrival and departure dates:
+// {"rentalId": "...", "start": "2024-07-01", "end": "2024-07-08", "message": "..."}.
+func (h *ReservationHandler) RequestReservation(c echo.Context) error {
+	var body struct {
+		RentalID string `json:"rentalId"`
+		Start    string `json:"start"`
+		End      string `json:"end"`
+		Message  string `json:"message"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+
+	start, err := time.Parse("2006-01-02", body.Start)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid start date, expected YYYY-MM-DD"})
+	}
+	end, err := time.Parse("2006-01-02", body.End)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid end date, expected YYYY-MM-DD"})
+	}
+
+	// Get user info from JWT claims
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	period := rentalTypes.DateRange{Start: start, End: end}
+	reservation, err := h.service.RequestReservation(c.Request().Context(), claims.UserID, body.RentalID, period, body.Message)
+	if err != nil {
+		return reservationError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, reservation)
+}
+
+// GetMyReservations handles the GET request to list the reservations of the authenticated user as a tenant.
+// The optional status parameter filters them, e.g. ?status=requested,accepted.
+func (h *ReservationHandler) GetMyReservations(c echo.Context) error {
+	status, err := parseStatus(c.QueryParam("status"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	reservations, err := h.service.GetTenantReservations(c.Request().Context(), claims.UserID, status)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve reservations"})
+	}
+
+	return c.JSON(http.StatusOK, reservations)
+}
+
+// GetIncomingReservations handles the GET request to list the reservations made on the rentals of the authenticated user.
+// The optional status parameter filters them, e.g. ?status=requested.
+func (h *ReservationHandler) GetIncomingReservations(c echo.Context) error {
+	status, err := parseStatus(c.QueryParam("status"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	reservations, err := h.service.GetOwnerReservations(c.Request().Context(), claims.UserID, status)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve reservations"})
+	}
+
+	return c.JSON(http.StatusOK, reservations)
+}
+
+// AcceptReservation handles the POST request of the owner accepting a request, which books its dates
+func (h *ReservationHandler) AcceptReservation(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	if err := h.service.AcceptReservation(c.Request().Context(), claims.UserID, c.Param("id")); err != nil {
+		return reservationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Reservation accepted successfully"})
+}
+
+// RejectReservation handles the POST request of the owner declining a request: {"reason": "..."}
+func (h *ReservationHandler) RejectReservation(c echo.Context) error {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	if err := h.service.RejectReservation(c.Request().Context(), claims.UserID, c.Param("id"), body.Reason); err != nil {
+		return reservationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Reservation rejected successfully"})
+}
+
+// CancelReservation handles the POST request of the tenant withdrawing a reservation
+func (h *ReservationHandler) CancelReservation(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	if err := h.service.CancelReservation(c.Request().Context(), claims.UserID, c.Param("id")); err != nil {
+		return reservationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Reservation cancelled successfully"})
+}
+
+func parseStatus(raw string) ([]types.Status, error) {
+	var status []types.Status
+	for _, s := range strings.Split(raw, ",") {
+		switch value := types.Status(strings.TrimSpace(s)); value {
+		case "":
+		case types.Requested, types.Accepted, types.Rejected, types.Cancelled:
+			status = append(status, value)
+		default:
+			return nil, fmt.Errorf("invalid status: %s", s)
+		}
+	}
+	return status, nil
+}
+
+func reservationError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrReservationNotFound), errors.Is(err, service.ErrRentalNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrNotAllowed):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrPeriodUnavailable), errors.Is(err, service.ErrInvalidTransition), errors.Is(err, service.ErrStatusConflict):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidID), errors.Is(err, service.ErrInvalidPeriod), errors.Is(err, service.ErrOwnRental):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to process the reservation"})
+	}
+}
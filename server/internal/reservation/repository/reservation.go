@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"server/internal/reservation/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrStatusConflict is returned when a reservation is no longer in the status a transition starts from
+var ErrStatusConflict = errors.New("reservation status has changed")
+
+type ReservationRepository interface {
+	CreateReservation(ctx context.Context, reservation *types.Reservation) error
+	GetReservationByID(ctx context.Context, id primitive.ObjectID) (*types.Reservation, error)
+	GetReservationsByTenantID(ctx context.Context, tenantID primitive.ObjectID, status []types.Status) ([]types.Reservation, error)
+	GetReservationsByOwnerID(ctx context.Context, ownerID primitive.ObjectID, status []types.Status) ([]types.Reservation, error)
+	GetOverlappingRequests(ctx context.Context, rentalID primitive.ObjectID, start, end time.Time) ([]types.Reservation, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, from, to types.Status, reason string) error
+}
+
+type reservationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReservationRepository(db *mongo.Database) ReservationRepository {
+	return &reservationRepository{
+		collection: db.Collection("reservations"),
+	}
+}
+
+// CreateReservation stores a new reservation request
+func (r *reservationRepository) CreateReservation(ctx context.Context, reservation *types.Reservation) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	reservation.ID = primitive.NewObjectID()
+	reservation.CreatedAt = time.Now()
+	reservation.UpdatedAt = reservation.CreatedAt
+
+	if _, err := r.collection.InsertOne(ctx, reservation); err != nil {
+		log.Printf("Error inserting reservation: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetReservationByID retrieves a reservation by its ID
+func (r *reservationRepository) GetReservationByID(ctx context.Context, id primitive.ObjectID) (*types.Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var reservation types.Reservation
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&reservation); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Error finding reservation: %v", err)
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// GetReservationsByTenantID retrieves the reservations a tenant made, optionally restricted to some statuses
+func (r *reservationRepository) GetReservationsByTenantID(ctx context.Context, tenantID primitive.ObjectID, status []types.Status) ([]types.Reservation, error) {
+	return r.find(ctx, withStatus(bson.M{"tenantId": tenantID}, status))
+}
+
+// GetReservationsByOwnerID retrieves the reservations made on the rentals of an owner, optionally restricted to some statuses
+func (r *reservationRepository) GetReservationsByOwnerID(ctx context.Context, ownerID primitive.ObjectID, status []types.Status) ([]types.Reservation, error) {
+	return r.find(ctx, withStatus(bson.M{"ownerId": ownerID}, status))
+}
+
+// GetOverlappingRequests retrieves the pending requests on a rental that share at least one day with the period
+func (r *reservationRepository) GetOverlappingRequests(ctx context.Context, rentalID primitive.ObjectID, start, end time.Time) ([]types.Reservation, error) {
+	return r.find(ctx, bson.M{
+		"rentalId": rentalID,
+		"status":   types.Requested,
+		"start":    bson.M{"$lt": end},
+		"end":      bson.M{"$gt": start},
+	})
+}
+
+// UpdateStatus moves a reservation from one status to another.
+// The update only applies if the reservation is still in the from status, so concurrent decisions cannot race.
+func (r *reservationRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, from, to types.Status, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{"status": to, "updatedAt": time.Now()}
+	if reason != "" {
+		set["reason"] = reason
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		log.Printf("Error updating reservation status: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+// find retrieves reservations by arrival date, soonest first
+func (r *reservationRepository) find(ctx context.Context, filter bson.M) ([]types.Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Error finding reservations: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []types.Reservation{}
+	if err = cursor.All(ctx, &reservations); err != nil {
+		log.Printf("Error decoding reservations: %v", err)
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func withStatus(filter bson.M, status []types.Status) bson.M {
+	if len(status) > 0 {
+		filter["status"] = bson.M{"$in": status}
+	}
+	return filter
+}
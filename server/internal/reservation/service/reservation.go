@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	rentalRepository "server/internal/rental/repository"
+	rentalTypes "server/internal/rental/types"
+	"server/internal/reservation/repository"
+	"server/internal/reservation/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// unavailableReason is given to the pending requests rejected because another one took their dates
+const unavailableReason = "The rental was booked for these dates"
+
+var (
+	// ErrReservationNotFound is returned when no reservation has the given ID
+	ErrReservationNotFound = errors.New("no reservation found with the given ID")
+	// ErrRentalNotFound is returned when the rental to book does not exist or is not published
+	ErrRentalNotFound = errors.New("no rental found with the given ID")
+	// ErrNotAllowed is returned when the user is not the party entitled to the action
+	ErrNotAllowed = errors.New("not allowed to act on this reservation")
+	// ErrInvalidTransition is returned when the reservation status does not allow the action
+	ErrInvalidTransition = errors.New("reservation status does not allow this action")
+	// ErrStatusConflict is returned when the reservation status changed while the action was being applied
+	ErrStatusConflict = repository.ErrStatusConflict
+	// ErrPeriodUnavailable is returned when the requested dates overlap a blocked or booked period
+	ErrPeriodUnavailable = rentalRepository.ErrPeriodUnavailable
+	// ErrInvalidID is returned when a user, rental or reservation ID is malformed
+	ErrInvalidID = errors.New("invalid ID format")
+	// ErrInvalidPeriod is returned when the requested dates cannot be booked whatever the calendar
+	ErrInvalidPeriod = errors.New("invalid period")
+	// ErrOwnRental is returned when a user requests to book their own rental
+	ErrOwnRental = errors.New("you cannot book your own rental")
+)
+
+type ReservationService interface {
+	RequestReservation(ctx context.Context, tenantID string, rentalID string, period rentalTypes.DateRange, message string) (*types.Reservation, error)
+	GetTenantReservations(ctx context.Context, tenantID string, status []types.Status) ([]types.Reservation, error)
+	GetOwnerReservations(ctx context.Context, ownerID string, status []types.Status) ([]types.Reservation, error)
+	AcceptReservation(ctx context.Context, ownerID string, id string) error
+	RejectReservation(ctx context.Context, ownerID string, id string, reason string) error
+	CancelReservation(ctx context.Context, tenantID string, id string) error
+}
+
+type reservationService struct {
+	repo        repository.ReservationRepository
+	rentalRepo  rentalRepository.RentalRepository
+	rentalAudit rentalRepository.AuditRepository
+}
+
+func NewReservationService(repo repository.ReservationRepository, rentalRepo rentalRepository.RentalRepository, rentalAudit rentalRepository.AuditRepository) ReservationService {
+	return &reservationService{repo: repo, rentalRepo: rentalRepo, rentalAudit: rentalAudit}
+}
+
+// RequestReservation asks the owner of a published rental to book it over a period.
+// The dates are checked against the rental calendar, but only an accepted reservation holds them.
+func (s *reservationService) RequestReservation(ctx context.Context, tenantID string, rentalID string, period rentalTypes.DateRange, message string) (*types.Reservation, error) {
+	tenant, err := primitive.ObjectIDFromHex(tenantID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if !primitive.IsValidObjectID(rentalID) {
+		return nil, ErrInvalidID
+	}
+
+	period.Start, period.End = rentalTypes.Day(period.Start), rentalTypes.Day(period.End)
+	if !period.End.After(period.Start) {
+		return nil, fmt.Errorf("%w: the departure date must be after the arrival date", ErrInvalidPeriod)
+	}
+	if period.Start.Before(rentalTypes.Day(time.Now())) {
+		return nil, fmt.Errorf("%w: the arrival date cannot be in the past", ErrInvalidPeriod)
+	}
+
+	rental, err := s.rentalRepo.GetRentalByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rental == nil || rental.Status != rentalTypes.Agreed {
+		return nil, ErrRentalNotFound
+	}
+	if rental.CreatedBy == tenant {
+		return nil, ErrOwnRental
+	}
+	for _, block := range rental.Availability {
+		if block.Overlaps(period) {
+			return nil, ErrPeriodUnavailable
+		}
+	}
+
+	reservation := &types.Reservation{
+		RentalID: rental.ID,
+		TenantID: tenant,
+		OwnerID:  rental.CreatedBy,
+		Start:    period.Start,
+		End:      period.End,
+		Message:  strings.TrimSpace(message),
+		Status:   types.Requested,
+	}
+	if err := s.repo.CreateReservation(ctx, reservation); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// GetTenantReservations retrieves the reservations a user made as a tenant
+func (s *reservationService) GetTenantReservations(ctx context.Context, tenantID string, status []types.Status) ([]types.Reservation, error) {
+	tenant, err := primitive.ObjectIDFromHex(tenantID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return s.repo.GetReservationsByTenantID(ctx, tenant, status)
+}
+
+// GetOwnerReservations retrieves the reservations made on the rentals of a user
+func (s *reservationService) GetOwnerReservations(ctx context.Context, ownerID string, status []types.Status) ([]types.Reservation, error) {
+	owner, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return s.repo.GetReservationsByOwnerID(ctx, owner, status)
+}
+
+// AcceptReservation books the period of a request in the rental calendar.
+// The booking fails with ErrPeriodUnavailable if the period overlaps a block or another accepted reservation,
+// the check and the booking being a single atomic update of the rental.
+// The other pending requests over the same dates are then rejected.
+func (s *reservationService) AcceptReservation(ctx context.Context, ownerID string, id string) error {
+	reservation, err := s.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation.OwnerID.Hex() != ownerID {
+		return ErrNotAllowed
+	}
+	if !reservation.Status.CanTransitionTo(types.Accepted) {
+		return ErrInvalidTransition
+	}
+
+	block := rentalTypes.AvailabilityBlock{
+		ID:     reservation.ID,
+		Kind:   rentalTypes.Booked,
+		Start:  reservation.Start,
+		End:    reservation.End,
+		Source: rentalTypes.ReservationSource,
+	}
+	previous := s.availability(ctx, reservation.RentalID)
+	if err := s.rentalRepo.BookPeriod(ctx, reservation.RentalID.Hex(), block); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateStatus(ctx, reservation.ID, types.Requested, types.Accepted, ""); err != nil {
+		// The request was cancelled meanwhile, give the dates back
+		if releaseErr := s.rentalRepo.RemoveAvailabilityBlock(ctx, reservation.RentalID.Hex(), reservation.ID); releaseErr != nil {
+			log.Printf("Failed to release the dates of reservation %s: %v", reservation.ID.Hex(), releaseErr)
+		}
+		return err
+	}
+
+	s.recordAvailability(ctx, reservation.RentalID, reservation.OwnerID, previous)
+	s.rejectOverlapping(ctx, *reservation)
+	return nil
+}
+
+// RejectReservation declines a pending request
+func (s *reservationService) RejectReservation(ctx context.Context, ownerID string, id string, reason string) error {
+	reservation, err := s.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation.OwnerID.Hex() != ownerID {
+		return ErrNotAllowed
+	}
+	if !reservation.Status.CanTransitionTo(types.Rejected) {
+		return ErrInvalidTransition
+	}
+
+	return s.repo.UpdateStatus(ctx, reservation.ID, types.Requested, types.Rejected, strings.TrimSpace(reason))
+}
+
+// CancelReservation withdraws a request or an accepted reservation, whose dates become available again
+func (s *reservationService) CancelReservation(ctx context.Context, tenantID string, id string) error {
+	reservation, err := s.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation.TenantID.Hex() != tenantID {
+		return ErrNotAllowed
+	}
+	if !reservation.Status.CanTransitionTo(types.Cancelled) {
+		return ErrInvalidTransition
+	}
+
+	if err := s.repo.UpdateStatus(ctx, reservation.ID, reservation.Status, types.Cancelled, ""); err != nil {
+		return err
+	}
+
+	if reservation.Status == types.Accepted {
+		previous := s.availability(ctx, reservation.RentalID)
+		err := s.rentalRepo.RemoveAvailabilityBlock(ctx, reservation.RentalID.Hex(), reservation.ID)
+		switch {
+		case err == nil:
+			s.recordAvailability(ctx, reservation.RentalID, reservation.TenantID, previous)
+		case !errors.Is(err, rentalRepository.ErrBlockNotFound):
+			log.Printf("Failed to release the dates of reservation %s: %v", reservation.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// availability returns the calendar of a rental before a reservation changes it, to be audited afterwards
+func (s *reservationService) availability(ctx context.Context, rentalID primitive.ObjectID) []rentalTypes.AvailabilityBlock {
+	rental, err := s.rentalRepo.GetRentalByID(ctx, rentalID.Hex())
+	if err != nil || rental == nil {
+		return nil
+	}
+	return rental.Availability
+}
+
+// recordAvailability records in the audit trail of a rental the calendar change made by a reservation
+func (s *reservationService) recordAvailability(ctx context.Context, rentalID primitive.ObjectID, actor primitive.ObjectID, previous []rentalTypes.AvailabilityBlock) {
+	rental, err := s.rentalRepo.GetRentalByID(ctx, rentalID.Hex())
+	if err != nil || rental == nil {
+		log.Printf("Failed to load rental %s for the audit trail: %v", rentalID.Hex(), err)
+		return
+	}
+
+	entry := rentalTypes.AuditEntry{
+		RentalID: rentalID,
+		Action:   rentalTypes.AuditUpdate,
+		Actor:    actor,
+		Changes:  []rentalTypes.FieldChange{{Field: "availability", Old: previous, New: rental.Availability}},
+		At:       time.Now(),
+	}
+	if err := s.rentalAudit.AddEntry(ctx, entry); err != nil {
+		log.Printf("Failed to record %s of rental %s in the audit trail: %v", entry.Action, rentalID.Hex(), err)
+	}
+}
+
+// rejectOverlapping rejects the pending requests that wanted some of the dates of an accepted reservation
+func (s *reservationService) rejectOverlapping(ctx context.Context, accepted types.Reservation) {
+	requests, err := s.repo.GetOverlappingRequests(ctx, accepted.RentalID, accepted.Start, accepted.End)
+	if err != nil {
+		log.Printf("Failed to find the requests overlapping reservation %s: %v", accepted.ID.Hex(), err)
+		return
+	}
+	for _, request := range requests {
+		err := s.repo.UpdateStatus(ctx, request.ID, types.Requested, types.Rejected, unavailableReason)
+		if err != nil && !errors.Is(err, ErrStatusConflict) {
+			log.Printf("Failed to reject reservation %s: %v", request.ID.Hex(), err)
+		}
+	}
+}
+
+func (s *reservationService) load(ctx context.Context, id string) (*types.Reservation, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	reservation, err := s.repo.GetReservationByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation == nil {
+		return nil, ErrReservationNotFound
+	}
+	return reservation, nil
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Status string
+
+const (
+	Requested Status = "requested"
+	Accepted  Status = "accepted"
+	Rejected  Status = "rejected"
+	Cancelled Status = "cancelled"
+)
+
+// statusTransitions lists the moves allowed from each status.
+// The owner accepts or rejects a request, the tenant can cancel it until it is rejected.
+var statusTransitions = map[Status][]Status{
+	Requested: {Accepted, Rejected, Cancelled},
+	Accepted:  {Cancelled},
+}
+
+// CanTransitionTo reports whether a reservation can move from status s to next
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Reservation is a request from a tenant to stay in a rental over a period of whole days.
+// Start is the arrival date and End the departure date, both at midnight UTC.
+type Reservation struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RentalID  primitive.ObjectID `json:"rentalId" bson:"rentalId"`
+	TenantID  primitive.ObjectID `json:"tenantId" bson:"tenantId"`
+	OwnerID   primitive.ObjectID `json:"ownerId" bson:"ownerId"` // CreatedBy of the rental when the request was made
+	Start     time.Time          `json:"start" bson:"start"`
+	End       time.Time          `json:"end" bson:"end"`
+	Message   string             `json:"message,omitempty" bson:"message,omitempty"`
+	Status    Status             `json:"status" bson:"status"`
+	Reason    string             `json:"reason,omitempty" bson:"reason,omitempty"` // Given by the owner when rejecting
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+
+	"server/config"
+	rentalUtils "server/internal/rental/utils"
+	"server/internal/savedsearch/service"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type SavedSearchHandler struct {
+	service service.SavedSearchService
+}
+
+func NewSavedSearchHandler(service service.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{service: service}
+}
+
+// CreateSavedSearch handles the POST request to save a search on the authenticated user account.
+// The body carries a name and the query string of the search: {"name": "...", "params": "minBedrooms=2&parking=true"}.
+func (h *SavedSearchHandler) CreateSavedSearch(c echo.Context) error {
+	var body struct {
+		Name   string `json:"name"`
+		Params string `json:"params"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data"})
+	}
+
+	// Get user info from JWT claims
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	search, err := h.service.CreateSavedSearch(c.Request().Context(), claims.UserID, body.Name, body.Params)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, search)
+}
+
+// GetSavedSearches handles the GET request to list the saved searches of the authenticated user
+func (h *SavedSearchHandler) GetSavedSearches(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	searches, err := h.service.GetSavedSearches(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve saved searches"})
+	}
+
+	return c.JSON(http.StatusOK, searches)
+}
+
+// DeleteSavedSearch handles the DELETE request to remove a saved search of the authenticated user
+func (h *SavedSearchHandler) DeleteSavedSearch(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	if err := h.service.DeleteSavedSearch(c.Request().Context(), claims.UserID, c.Param("id")); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Saved search deleted successfully"})
+}
+
+// GetUnseenMatches handles the GET request to list the unseen matches of each saved search
+func (h *SavedSearchHandler) GetUnseenMatches(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	matches, err := h.service.GetUnseenMatches(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve matches"})
+	}
+
+	// Convert image file paths to public URLs
+	for i := range matches {
+		for j := range matches[i].Rentals {
+			matches[i].Rentals[j].Images = rentalUtils.MapImagePathsToURLs(c, matches[i].Rentals[j].Images)
+		}
+	}
+
+	return c.JSON(http.StatusOK, matches)
+}
+
+// MarkSeen handles the POST request to mark the matches of a saved search as seen
+func (h *SavedSearchHandler) MarkSeen(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	if err := h.service.MarkSeen(c.Request().Context(), claims.UserID, c.Param("id")); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Matches marked as seen"})
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"server/internal/savedsearch/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SavedSearchRepository interface {
+	CreateSavedSearch(ctx context.Context, search *types.SavedSearch) error
+	GetAllSavedSearches(ctx context.Context) ([]types.SavedSearch, error)
+	GetSavedSearchesByUserID(ctx context.Context, userID primitive.ObjectID) ([]types.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, userID, id primitive.ObjectID) error
+	AddMatch(ctx context.Context, match types.Match) error
+	GetUnseenMatches(ctx context.Context, userID primitive.ObjectID) ([]types.Match, error)
+	MarkMatchesSeen(ctx context.Context, userID, savedSearchID primitive.ObjectID) error
+}
+
+type savedSearchRepository struct {
+	searches *mongo.Collection
+	matches  *mongo.Collection
+}
+
+func NewSavedSearchRepository(db *mongo.Database) SavedSearchRepository {
+	return &savedSearchRepository{
+		searches: db.Collection("saved_searches"),
+		matches:  db.Collection("saved_search_matches"),
+	}
+}
+
+// CreateSavedSearch stores a new saved search
+func (r *savedSearchRepository) CreateSavedSearch(ctx context.Context, search *types.SavedSearch) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	search.ID = primitive.NewObjectID()
+	search.CreatedAt = time.Now()
+
+	if _, err := r.searches.InsertOne(ctx, search); err != nil {
+		log.Printf("Error inserting saved search: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetAllSavedSearches retrieves the saved searches of every user
+func (r *savedSearchRepository) GetAllSavedSearches(ctx context.Context) ([]types.SavedSearch, error) {
+	return r.find(ctx, bson.M{})
+}
+
+// GetSavedSearchesByUserID retrieves the saved searches of a user, newest first
+func (r *savedSearchRepository) GetSavedSearchesByUserID(ctx context.Context, userID primitive.ObjectID) ([]types.SavedSearch, error) {
+	return r.find(ctx, bson.M{"userId": userID})
+}
+
+func (r *savedSearchRepository) find(ctx context.Context, filter bson.M) ([]types.SavedSearch, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.searches.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		log.Printf("Error finding saved searches: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	searches := []types.SavedSearch{}
+	if err = cursor.All(ctx, &searches); err != nil {
+		log.Printf("Error decoding saved searches: %v", err)
+		return nil, err
+	}
+	return searches, nil
+}
+
+// DeleteSavedSearch deletes a saved search of the user along with its matches
+func (r *savedSearchRepository) DeleteSavedSearch(ctx context.Context, userID, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.searches.DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		log.Printf("Error deleting saved search: %v", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("no saved search found with the given ID")
+	}
+
+	if _, err := r.matches.DeleteMany(ctx, bson.M{"savedSearchId": id}); err != nil {
+		log.Printf("Error deleting saved search matches: %v", err)
+		return err
+	}
+	return nil
+}
+
+// AddMatch records a match. Recording the same rental twice for a search keeps the first match.
+func (r *savedSearchRepository) AddMatch(ctx context.Context, match types.Match) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"savedSearchId": match.SavedSearchID, "rentalId": match.RentalID}
+	update := bson.M{"$setOnInsert": bson.M{
+		"userId":    match.UserID,
+		"matchedAt": match.MatchedAt,
+		"seen":      false,
+	}}
+
+	if _, err := r.matches.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		log.Printf("Error recording saved search match: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetUnseenMatches retrieves the unseen matches of all the saved searches of a user, newest first
+func (r *savedSearchRepository) GetUnseenMatches(ctx context.Context, userID primitive.ObjectID) ([]types.Match, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "matchedAt", Value: -1}})
+	cursor, err := r.matches.Find(ctx, bson.M{"userId": userID, "seen": false}, opts)
+	if err != nil {
+		log.Printf("Error finding saved search matches: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	matches := []types.Match{}
+	if err = cursor.All(ctx, &matches); err != nil {
+		log.Printf("Error decoding saved search matches: %v", err)
+		return nil, err
+	}
+	return matches, nil
+}
+
+// MarkMatchesSeen marks every match of a saved search of the user as seen
+func (r *savedSearchRepository) MarkMatchesSeen(ctx context.Context, userID, savedSearchID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userID, "savedSearchId": savedSearchID, "seen": false}
+	if _, err := r.matches.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"seen": true}}); err != nil {
+		log.Printf("Error marking saved search matches as seen: %v", err)
+		return err
+	}
+	return nil
+}
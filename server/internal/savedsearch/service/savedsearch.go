@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	rentalRepository "server/internal/rental/repository"
+	rentalTypes "server/internal/rental/types"
+	rentalUtils "server/internal/rental/utils"
+	"server/internal/savedsearch/repository"
+	"server/internal/savedsearch/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SavedSearchService interface {
+	CreateSavedSearch(ctx context.Context, userID, name, params string) (*types.SavedSearch, error)
+	GetSavedSearches(ctx context.Context, userID string) ([]types.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, userID, id string) error
+	GetUnseenMatches(ctx context.Context, userID string) ([]types.SavedSearchMatches, error)
+	MarkSeen(ctx context.Context, userID, id string) error
+	RentalPublished(ctx context.Context, rental rentalTypes.Rental)
+}
+
+// PriceNormaliser converts an amount to TND, it is implemented by the currency service
+type PriceNormaliser interface {
+	ToBase(ctx context.Context, amount int64, currency string) (int64, error)
+}
+
+type savedSearchService struct {
+	repo       repository.SavedSearchRepository
+	rentalRepo rentalRepository.RentalRepository
+	prices     PriceNormaliser
+}
+
+func NewSavedSearchService(repo repository.SavedSearchRepository, rentalRepo rentalRepository.RentalRepository, prices PriceNormaliser) SavedSearchService {
+	return &savedSearchService{repo: repo, rentalRepo: rentalRepo, prices: prices}
+}
+
+// CreateSavedSearch validates the search parameters and saves them on the user account
+func (s *savedSearchService) CreateSavedSearch(ctx context.Context, userID, name, params string) (*types.SavedSearch, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+
+	params = strings.TrimPrefix(params, "?")
+	if _, err := parseParams(params); err != nil {
+		return nil, err
+	}
+
+	search := &types.SavedSearch{UserID: ownerID, Name: name, Params: params}
+	if err := s.repo.CreateSavedSearch(ctx, search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+// GetSavedSearches retrieves the saved searches of a user with their number of unseen matches
+func (s *savedSearchService) GetSavedSearches(ctx context.Context, userID string) ([]types.SavedSearch, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	searches, err := s.repo.GetSavedSearchesByUserID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := s.repo.GetUnseenMatches(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	unseen := map[primitive.ObjectID]int64{}
+	for _, match := range matches {
+		unseen[match.SavedSearchID]++
+	}
+	for i := range searches {
+		searches[i].UnseenCount = unseen[searches[i].ID]
+	}
+	return searches, nil
+}
+
+// DeleteSavedSearch deletes a saved search of the user
+func (s *savedSearchService) DeleteSavedSearch(ctx context.Context, userID, id string) error {
+	ownerID, searchID, err := parseIDs(userID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteSavedSearch(ctx, ownerID, searchID)
+}
+
+// GetUnseenMatches lists, for each saved search of the user, the matching rentals not seen yet.
+// Rentals that have been removed or are no longer published since they matched are left out.
+func (s *savedSearchService) GetUnseenMatches(ctx context.Context, userID string) ([]types.SavedSearchMatches, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	searches, err := s.repo.GetSavedSearchesByUserID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := s.repo.GetUnseenMatches(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rentalIDs []primitive.ObjectID
+	for _, match := range matches {
+		rentalIDs = append(rentalIDs, match.RentalID)
+	}
+	rentals, err := s.rentalRepo.GetPublishedRentalsByIDs(ctx, rentalIDs)
+	if err != nil {
+		return nil, err
+	}
+	rentalsByID := map[primitive.ObjectID]rentalTypes.Rental{}
+	for _, rental := range rentals {
+		rentalsByID[rental.ID] = rental
+	}
+
+	rentalsBySearch := map[primitive.ObjectID][]rentalTypes.Rental{}
+	for _, match := range matches {
+		if rental, ok := rentalsByID[match.RentalID]; ok {
+			rentalsBySearch[match.SavedSearchID] = append(rentalsBySearch[match.SavedSearchID], rental)
+		}
+	}
+
+	result := []types.SavedSearchMatches{}
+	for _, search := range searches {
+		if matched := rentalsBySearch[search.ID]; len(matched) > 0 {
+			search.UnseenCount = int64(len(matched))
+			result = append(result, types.SavedSearchMatches{SavedSearch: search, Rentals: matched})
+		}
+	}
+	return result, nil
+}
+
+// MarkSeen marks the matches of a saved search as seen
+func (s *savedSearchService) MarkSeen(ctx context.Context, userID, id string) error {
+	ownerID, searchID, err := parseIDs(userID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.MarkMatchesSeen(ctx, ownerID, searchID)
+}
+
+// RentalPublished flags the rental on every saved search it matches.
+// Errors are logged rather than returned, a failed match must not fail the publication.
+func (s *savedSearchService) RentalPublished(ctx context.Context, rental rentalTypes.Rental) {
+	searches, err := s.repo.GetAllSavedSearches(ctx)
+	if err != nil {
+		log.Printf("Failed to load saved searches: %v", err)
+		return
+	}
+
+	for _, search := range searches {
+		// Landlords do not need to be told about their own listings
+		if search.UserID == rental.CreatedBy {
+			continue
+		}
+
+		query, err := parseParams(search.Params)
+		if err != nil {
+			log.Printf("Skipping saved search %s: %v", search.ID.Hex(), err)
+			continue
+		}
+		// Price bounds may be in another currency than the TND prices are compared in
+		err = rentalUtils.NormalisePriceRange(&query, func(amount int64, currency string) (int64, error) {
+			return s.prices.ToBase(ctx, amount, currency)
+		})
+		if err != nil {
+			log.Printf("Skipping saved search %s: %v", search.ID.Hex(), err)
+			continue
+		}
+		matches, err := s.rentalRepo.MatchesQuery(ctx, rental.ID, query)
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Printf("Stopped matching rental %s against saved searches: %v", rental.ID.Hex(), ctx.Err())
+				return
+			}
+			continue
+		}
+		if !matches {
+			continue
+		}
+
+		match := types.Match{
+			SavedSearchID: search.ID,
+			UserID:        search.UserID,
+			RentalID:      rental.ID,
+			MatchedAt:     time.Now(),
+		}
+		if err := s.repo.AddMatch(ctx, match); err != nil {
+			log.Printf("Failed to record match of rental %s for saved search %s: %v", rental.ID.Hex(), search.ID.Hex(), err)
+		}
+	}
+}
+
+// parseParams parses the query string of a saved search with the rental search parser
+func parseParams(params string) (rentalTypes.RentalQuery, error) {
+	values, err := url.ParseQuery(params)
+	if err != nil {
+		return rentalTypes.RentalQuery{}, errors.New("invalid search parameters")
+	}
+	return rentalUtils.ParseRentalQuery(values)
+}
+
+func parseIDs(userID, id string) (primitive.ObjectID, primitive.ObjectID, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid user ID")
+	}
+	searchID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid saved search ID")
+	}
+	return ownerID, searchID, nil
+}
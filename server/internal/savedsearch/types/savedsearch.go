@@ -0,0 +1,37 @@
+package types
+
+import (
+	"time"
+
+	rentalTypes "server/internal/rental/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedSearch is a rental search stored on a user account.
+// Params is the query string of the search, exactly as sent to /api/rental/list,
+// so the filters and the area are parsed the same way as a live search.
+type SavedSearch struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `json:"userId" bson:"userId"`
+	Name        string             `json:"name" bson:"name" validate:"required,max=100"`
+	Params      string             `json:"params" bson:"params"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UnseenCount int64              `json:"unseenCount" bson:"-"`
+}
+
+// Match records that a published rental matched a saved search
+type Match struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SavedSearchID primitive.ObjectID `json:"savedSearchId" bson:"savedSearchId"`
+	UserID        primitive.ObjectID `json:"userId" bson:"userId"`
+	RentalID      primitive.ObjectID `json:"rentalId" bson:"rentalId"`
+	MatchedAt     time.Time          `json:"matchedAt" bson:"matchedAt"`
+	Seen          bool               `json:"seen" bson:"seen"`
+}
+
+// SavedSearchMatches lists the unseen rentals matching a saved search
+type SavedSearchMatches struct {
+	SavedSearch SavedSearch          `json:"savedSearch"`
+	Rentals     []rentalTypes.Rental `json:"rentals"`
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"server/config"
+
+	currencyRepository "server/internal/currency/repository"
+	currencyService "server/internal/currency/service"
+	favoriteRepository "server/internal/favorite/repository"
+	favoriteService "server/internal/favorite/service"
+	rentalRepository "server/internal/rental/repository"
+	rentalService "server/internal/rental/service"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PurgeDeletedRentals permanently removes the rentals soft deleted more than the given number of days ago,
+// including their image folders and the favorites pointing to them.
+func PurgeDeletedRentals(cfg *config.Config, days int) error {
+	if days < 0 {
+		return errors.New("days cannot be negative")
+	}
+
+	db, err := NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rentalRepo := rentalRepository.NewRentalRepository(db.database)
+	service := rentalService.NewRentalService(
+		rentalRepo,
+		rentalRepository.NewAuditRepository(db.database),
+		nil, // Purging does not touch prices
+		expiryPolicy(cfg),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	count, err := service.PurgeDeletedRentals(ctx, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Purged %d rentals deleted more than %d days ago.", count, days)
+
+	favorites := favoriteService.NewFavoriteService(favoriteRepository.NewFavoriteRepository(db.database), rentalRepo)
+	orphans, err := favorites.DeleteOrphans(ctx)
+	if err != nil {
+		return err
+	}
+	if orphans > 0 {
+		log.Printf("Deleted %d favorites of purged rentals.", orphans)
+	}
+	return nil
+}
+
+// LoadExchangeRates loads the exchange rates of a JSON or CSV file, the format being given by its extension,
+// and reprices the rentals.
+func LoadExchangeRates(cfg *config.Config, path string) error {
+	if path == "" {
+		return errors.New("a rates file is required")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	db, err := NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	service := currencyService.NewCurrencyService(
+		currencyRepository.NewRateRepository(db.database),
+		rentalRepository.NewRentalRepository(db.database),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	table, err := service.ImportRates(ctx, file, format, primitive.NilObjectID)
+	if err != nil {
+		return err
+	}
+
+	for _, rate := range table.Rates {
+		log.Printf("1 %s = %g %s", rate.Currency, rate.Rate, table.Base)
+	}
+	return nil
+}
+
+// ImportRentals lists the rentals of a CSV or JSON Lines file on behalf of the given owner,
+// the format being given by its extension. A dry run only validates the rows.
+func ImportRentals(cfg *config.Config, path string, owner string, dryRun bool) error {
+	if path == "" {
+		return errors.New("an import file is required")
+	}
+	ownerID, err := primitive.ObjectIDFromHex(owner)
+	if err != nil {
+		return errors.New("the owner must be a user ID")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	db, err := NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rentalRepo := rentalRepository.NewRentalRepository(db.database)
+	service := rentalService.NewRentalService(
+		rentalRepo,
+		rentalRepository.NewAuditRepository(db.database),
+		currencyService.NewCurrencyService(currencyRepository.NewRateRepository(db.database), rentalRepo),
+		expiryPolicy(cfg),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	report, err := service.ImportRentals(ctx, file, format, ownerID, dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		switch {
+		case len(row.Details) > 0:
+			log.Printf("line %d: %s %q: %s %v", row.Line, row.Outcome, row.Name, row.Error, row.Details)
+		case row.Error != "":
+			log.Printf("line %d: %s %q: %s", row.Line, row.Outcome, row.Name, row.Error)
+		default:
+			log.Printf("line %d: %s %q %s", row.Line, row.Outcome, row.Name, row.ID)
+		}
+	}
+	if dryRun {
+		log.Printf("Dry run, nothing was written.")
+	}
+	log.Printf("%d created, %d skipped, %d failed.", report.Created, report.Skipped, report.Failed)
+	return nil
+}
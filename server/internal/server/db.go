@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"server/config"
+	"server/internal/geo"
 	types "server/internal/rental/types"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -63,6 +64,206 @@ func (db *DB) GetCollection(name string) *mongo.Collection {
 	return db.database.Collection(name)
 }
 
+// EnsureIndexes creates the indexes the repositories rely on. Creating an existing index is a no-op.
+func (db *DB) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := db.GetCollection("rentals").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+		{
+			// Listings mix French and Arabic, so no language specific stemming or stop words are applied
+			Keys: bson.D{
+				{Key: "name", Value: "text"},
+				{Key: "description", Value: "text"},
+				{Key: "tags", Value: "text"},
+				{Key: "address.fullAddress", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("rental_text").
+				SetDefaultLanguage("none").
+				SetLanguageOverride("textLanguage").
+				SetWeights(bson.D{
+					{Key: "name", Value: 10},
+					{Key: "tags", Value: 5},
+					{Key: "address.fullAddress", Value: 3},
+					{Key: "description", Value: 1},
+				}),
+		},
+		// Background jobs look for the drafts due for publication and the listings due to expire
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "publishAt", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}}},
+		// Duplicate detection looks for listings at the same address or sharing a picture
+		{Keys: bson.D{{Key: "addressKey", Value: 1}, {Key: "bedrooms", Value: 1}}},
+		{Keys: bson.D{{Key: "imageBands", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Failed to create rental indexes: %v", err)
+		return err
+	}
+
+	_, err = db.GetCollection("saved_searches").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	if err != nil {
+		log.Printf("Failed to create saved search indexes: %v", err)
+		return err
+	}
+
+	_, err = db.GetCollection("saved_search_matches").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "savedSearchId", Value: 1}, {Key: "rentalId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "seen", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Failed to create saved search match indexes: %v", err)
+		return err
+	}
+
+	_, err = db.GetCollection("favorites").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "rentalId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "rentalId", Value: 1}}},
+		{Keys: bson.D{{Key: "rentalId", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Failed to create favorite indexes: %v", err)
+		return err
+	}
+
+	_, err = db.GetCollection("rental_audit").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "rentalId", Value: 1}, {Key: "at", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("Failed to create rental audit index: %v", err)
+		return err
+	}
+
+	_, err = db.GetCollection("reservations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "start", Value: 1}}},
+		{Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "start", Value: 1}}},
+		{Keys: bson.D{{Key: "rentalId", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Failed to create reservation indexes: %v", err)
+		return err
+	}
+
+	_, err = db.GetCollection("viewing_slots").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "rentalId", Value: 1}, {Key: "status", Value: 1}, {Key: "start", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("Failed to create viewing slot index: %v", err)
+		return err
+	}
+
+	// A tenant holds at most one confirmed seat per slot, cancelled bookings do not count
+	_, err = db.GetCollection("viewing_bookings").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "slotId", Value: 1}, {Key: "tenantId", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "confirmed"}),
+		},
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "start", Value: 1}}},
+		{Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "start", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Failed to create viewing booking indexes: %v", err)
+		return err
+	}
+
+	log.Println("Indexes ensured successfully.")
+	return nil
+}
+
+// MigrateRentalLocations fills the GeoJSON location of rentals stored before it existed,
+// converting the string geometry. Rentals whose geometry cannot be converted are left without location.
+func (db *DB) MigrateRentalLocations() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	toDouble := func(field string) bson.M {
+		return bson.M{"$convert": bson.M{"input": field, "to": "double", "onError": nil, "onNull": nil}}
+	}
+
+	filter := bson.M{"location": bson.M{"$exists": false}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"location": bson.M{"$cond": bson.M{
+				"if": bson.M{"$and": bson.A{
+					bson.M{"$ne": bson.A{toDouble("$geometry.lat"), nil}},
+					bson.M{"$ne": bson.A{toDouble("$geometry.lng"), nil}},
+				}},
+				"then": bson.M{
+					"type":        "Point",
+					"coordinates": bson.A{toDouble("$geometry.lng"), toDouble("$geometry.lat")},
+				},
+				"else": "$$REMOVE",
+			}},
+		}}},
+	}
+
+	result, err := db.GetCollection("rentals").UpdateMany(ctx, filter, update)
+	if err != nil {
+		log.Printf("Failed to migrate rental locations: %v", err)
+		return err
+	}
+
+	log.Printf("Migrated the location of %d rentals.", result.ModifiedCount)
+	return nil
+}
+
+// MigrateRentalDuplicateKeys fills the normalised address and the image hash bands that duplicate detection
+// looks up on the rentals stored before they existed
+func (db *DB) MigrateRentalDuplicateKeys() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	collection := db.GetCollection("rentals")
+	opts := options.Find().SetProjection(bson.M{"address": 1, "imageHashes": 1})
+	cursor, err := collection.Find(ctx, bson.M{"addressKey": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		log.Printf("Failed to find rentals without duplicate keys: %v", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var rental types.Rental
+		if err := cursor.Decode(&rental); err != nil {
+			log.Printf("Failed to decode rental: %v", err)
+			return err
+		}
+		set := bson.M{"addressKey": rental.Address.Key()}
+		if bands := types.ImageHashBands(rental.ImageHashes); len(bands) > 0 {
+			set["imageBands"] = bands
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": rental.ID}).SetUpdate(bson.M{"$set": set}))
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Failed to read rentals without duplicate keys: %v", err)
+		return err
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	result, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		log.Printf("Failed to migrate rental duplicate keys: %v", err)
+		return err
+	}
+
+	log.Printf("Migrated the duplicate keys of %d rentals.", result.ModifiedCount)
+	return nil
+}
+
 func randomLatLngInTunis() (string, string) {
 	rand.Seed(time.Now().UnixNano())
 	lat := 36.74 + rand.Float64()*(36.88-36.74) // Latitude: 36.74 to 36.88
@@ -87,6 +288,7 @@ func (db *DB) InitMockRentals() error {
 	var rentals []types.Rental
 	for i := 0; i < 300; i++ {
 		var lat, lng = randomLatLngInTunis()
+		location, _ := geo.ParsePoint(lat, lng)
 
 		rental := types.Rental{
 			ID:   primitive.NewObjectID(),
@@ -102,6 +304,7 @@ func (db *DB) InitMockRentals() error {
 				Lat: lat,
 				Lng: lng,
 			},
+			Location:    &location,
 			Price:       int64(gofakeit.Number(500, 2000)),
 			Bedrooms:    int64(gofakeit.Number(1, 5)),
 			Bathrooms:   int64(gofakeit.Number(1, 3)),
@@ -129,6 +332,7 @@ func (db *DB) InitMockRentals() error {
 			CreatedBy: primitive.NewObjectID(), // Mock user ID
 			UpdatedBy: primitive.NewObjectID(), // Mock user ID
 		}
+		rental.AddressKey = rental.Address.Key()
 		rentals = append(rentals, rental)
 	}
 
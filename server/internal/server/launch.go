@@ -8,18 +8,38 @@ import (
 	"os"
 	"os/signal"
 	"server/config"
+	currencyHandler "server/internal/currency/handler"
+	currencyRepository "server/internal/currency/repository"
+	currencyService "server/internal/currency/service"
+	favoriteHandler "server/internal/favorite/handler"
+	favoriteRepository "server/internal/favorite/repository"
+	favoriteService "server/internal/favorite/service"
 	"server/internal/places/handler"
 	"server/internal/places/service"
 
 	rentalHandler "server/internal/rental/handler"
 	rentalRepository "server/internal/rental/repository"
 	rentalService "server/internal/rental/service"
+	rentalTypes "server/internal/rental/types"
+	rentalUtils "server/internal/rental/utils"
 
 	userHandler "server/internal/user/handler"
 	userRepository "server/internal/user/repository"
 	userService "server/internal/user/service"
 
+	savedSearchHandler "server/internal/savedsearch/handler"
+	savedSearchRepository "server/internal/savedsearch/repository"
+	savedSearchService "server/internal/savedsearch/service"
+
+	reservationHandler "server/internal/reservation/handler"
+	reservationRepository "server/internal/reservation/repository"
+	reservationService "server/internal/reservation/service"
+	viewingHandler "server/internal/viewing/handler"
+	viewingRepository "server/internal/viewing/repository"
+	viewingService "server/internal/viewing/service"
+
 	authHandler "server/internal/auth/handler"
+	"server/internal/scheduler"
 
 	"syscall"
 	"time"
@@ -30,6 +50,7 @@ import (
 type Server struct {
 	router *Router
 	Db     *DB
+	jobs   []scheduler.Job
 }
 
 // SetupRouter initializes routing handlers using services and repositories
@@ -41,9 +62,88 @@ func (s *Server) SetupRouter(e *echo.Echo, cfg *config.Config) {
 
 	// Initialize the rental repository, service, and handler
 	rentalRepo := rentalRepository.NewRentalRepository(s.Db.database)
-	rentalService := rentalService.NewRentalService(rentalRepo)
+
+	// Prices are normalised to TND for searching, and repriced whenever the exchange rates change
+	rateRepo := currencyRepository.NewRateRepository(s.Db.database)
+	currencyService := currencyService.NewCurrencyService(rateRepo, rentalRepo)
+	currencyHandler := currencyHandler.NewCurrencyHandler(currencyService)
+	if _, err := currencyService.RepriceRentals(context.Background()); err != nil {
+		log.Printf("Failed to reprice rentals: %v", err)
+	}
+
+	// Saved searches are matched against every published rental
+	savedSearchRepo := savedSearchRepository.NewSavedSearchRepository(s.Db.database)
+	savedSearchService := savedSearchService.NewSavedSearchService(savedSearchRepo, rentalRepo, currencyService)
+	savedSearchHandler := savedSearchHandler.NewSavedSearchHandler(savedSearchService)
+
+	rentalAuditRepo := rentalRepository.NewAuditRepository(s.Db.database)
+	rentalService := rentalService.NewRentalService(rentalRepo, rentalAuditRepo, currencyService, expiryPolicy(cfg), savedSearchService)
 	rentalHandler := rentalHandler.NewRentalHandler(rentalService, userService)
 
+	// Drafts scheduled for publication are submitted for moderation when they are due
+	s.jobs = append(s.jobs, scheduler.Job{
+		Name:     "publish-due-drafts",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			published, err := rentalService.PublishDueDrafts(ctx)
+			if published > 0 {
+				log.Printf("Published %d scheduled drafts", published)
+			}
+			return err
+		},
+	})
+
+	// Listings leave search once their lifetime is over
+	s.jobs = append(s.jobs, scheduler.Job{
+		Name:     "expire-rentals",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			expired, err := rentalService.ExpireRentals(ctx)
+			if expired > 0 {
+				log.Printf("Expired %d rentals", expired)
+			}
+			return err
+		},
+	})
+
+	// Calendars of other listing sites are imported again so that their bookings close the same dates here
+	s.jobs = append(s.jobs, scheduler.Job{
+		Name:     "sync-calendar-feeds",
+		Interval: rentalTypes.FeedSyncInterval,
+		Run: func(ctx context.Context) error {
+			_, err := rentalService.SyncCalendarFeeds(ctx)
+			return err
+		},
+	})
+
+	// Uploads never claimed by a rental are dropped after a day
+	s.jobs = append(s.jobs, scheduler.Job{
+		Name:     "purge-staged-images",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			removed, err := rentalUtils.PurgeStagedImages(rentalUtils.StagedImageLifetime)
+			if removed > 0 {
+				log.Printf("Removed %d abandoned image uploads", removed)
+			}
+			return err
+		},
+	})
+
+	// Favorites only list the rentals that are still published
+	favoriteRepo := favoriteRepository.NewFavoriteRepository(s.Db.database)
+	favoriteService := favoriteService.NewFavoriteService(favoriteRepo, rentalRepo)
+	favoriteHandler := favoriteHandler.NewFavoriteHandler(favoriteService)
+
+	// Accepted reservations book their dates in the rental calendar
+	reservationRepo := reservationRepository.NewReservationRepository(s.Db.database)
+	reservationService := reservationService.NewReservationService(reservationRepo, rentalRepo, rentalAuditRepo)
+	reservationHandler := reservationHandler.NewReservationHandler(reservationService)
+
+	// Viewing slots are published by the owner of the rental, invitations carry the user emails
+	viewingRepo := viewingRepository.NewViewingRepository(s.Db.database)
+	viewingService := viewingService.NewViewingService(viewingRepo, rentalRepo, userService)
+	viewingHandler := viewingHandler.NewViewingHandler(viewingService)
+
 	// Create the PlacesService using the API key from config
 	placesService := service.NewPlacesService(cfg.GooglePlacesAPIKey)
 
@@ -55,16 +155,34 @@ func (s *Server) SetupRouter(e *echo.Echo, cfg *config.Config) {
 	authHandler := authHandler.NewOAuthHandler(userService)
 	// Initialize the Router with both handlers
 	s.router = &Router{
-		PlacesHandler: placesHandler,
-		RentalHandler: rentalHandler,
-		UserHandler:   userHandler,
-		AuthHandler:   authHandler,
+		PlacesHandler:      placesHandler,
+		RentalHandler:      rentalHandler,
+		UserHandler:        userHandler,
+		AuthHandler:        authHandler,
+		SavedSearchHandler: savedSearchHandler,
+		ReservationHandler: reservationHandler,
+		ViewingHandler:     viewingHandler,
+		CurrencyHandler:    currencyHandler,
+		FavoriteHandler:    favoriteHandler,
 	}
 
 	// Initialize routes
 	s.router.Init(e)
 }
 
+// expiryPolicy builds the lifetime of published listings from the configuration
+func expiryPolicy(cfg *config.Config) rentalTypes.ExpiryPolicy {
+	day := 24 * time.Hour
+	return rentalTypes.ExpiryPolicy{
+		Lifetimes: map[rentalTypes.RentalType]time.Duration{
+			rentalTypes.Shared:      time.Duration(cfg.SharedLifetimeDays) * day,
+			rentalTypes.Independent: time.Duration(cfg.IndependentLifetimeDays) * day,
+			rentalTypes.Sale:        time.Duration(cfg.SaleLifetimeDays) * day,
+		},
+		Warning: time.Duration(cfg.ExpiryWarningDays) * day,
+	}
+}
+
 // SetupAndLaunch launches the server
 func (s *Server) SetupAndLaunch(e *echo.Echo, cfg *config.Config) {
 	// Use the config values directly instead of reading environment variables
@@ -87,12 +205,27 @@ func (s *Server) SetupAndLaunch(e *echo.Echo, cfg *config.Config) {
 	defer mongoDB.Close()
 
 	s.Db = mongoDB
+	// Geo searches and duplicate detection need the fields derived from older rentals, and their indexes
+	if err := s.Db.MigrateRentalLocations(); err != nil {
+		log.Fatalf("Error migrating rental locations: %v", err)
+	}
+	if err := s.Db.MigrateRentalDuplicateKeys(); err != nil {
+		log.Fatalf("Error migrating rental duplicate keys: %v", err)
+	}
+	if err := s.Db.EnsureIndexes(); err != nil {
+		log.Fatalf("Error creating indexes: %v", err)
+	}
 	s.Db.InitMockRentals()
 	s.Db.InitAdminUser()
 
 	// Initialize router with configuration
 	s.SetupRouter(e, cfg)
 
+	// Run the background jobs until the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	scheduler.Start(jobsCtx, s.jobs...)
+
 	// Start the server in a goroutine
 	go func() {
 		if err := e.Start(fmt.Sprintf(":%d", port)); err != nil && err != http.ErrServerClosed {
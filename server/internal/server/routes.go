@@ -8,7 +8,18 @@ import (
 
 	userHandler "server/internal/user/handler"
 
+	savedSearchHandler "server/internal/savedsearch/handler"
+
+	reservationHandler "server/internal/reservation/handler"
+
+	viewingHandler "server/internal/viewing/handler"
+
+	currencyHandler "server/internal/currency/handler"
+
+	favoriteHandler "server/internal/favorite/handler"
+
 	authHandler "server/internal/auth/handler"
+	authMiddleware "server/internal/auth/middleware"
 
 	"fmt"
 	"log"
@@ -20,10 +31,15 @@ import (
 // Router struct with a field for the places handler
 // More handlers will be added
 type Router struct {
-	PlacesHandler *placesHandler.PlacesHandler
-	RentalHandler *rentalHandler.RentalHandler
-	UserHandler   *userHandler.UserHandler
-	AuthHandler   *authHandler.OAuthHandler
+	PlacesHandler      *placesHandler.PlacesHandler
+	RentalHandler      *rentalHandler.RentalHandler
+	UserHandler        *userHandler.UserHandler
+	AuthHandler        *authHandler.OAuthHandler
+	SavedSearchHandler *savedSearchHandler.SavedSearchHandler
+	ReservationHandler *reservationHandler.ReservationHandler
+	ViewingHandler     *viewingHandler.ViewingHandler
+	CurrencyHandler    *currencyHandler.CurrencyHandler
+	FavoriteHandler    *favoriteHandler.FavoriteHandler
 }
 
 func (router *Router) Init(e *echo.Echo) {
@@ -32,32 +48,99 @@ func (router *Router) Init(e *echo.Echo) {
 		return c.String(http.StatusOK, "All Good!")
 	})
 
-	// Protected Routes
-	// Middleware for JWT protection (to be implemented)
+	// Protected routes use authMiddleware.RequireAuth, which stores the JWT under "user"
 	apiGroup := e.Group("/api")
-	// apiGroup.Use(middleware.JWTWithConfig(middleware.JWTConfig{
-	//	SigningKey: []byte("your-secret-key"),
-	//	Claims:     &config.JWTClaims{},
-	// }))
 
 	apiGroup.POST("/auth/google/login", router.AuthHandler.GoogleLogin)
 	apiGroup.GET("/auth/google/callback", router.AuthHandler.GoogleCallback)
-	apiGroup.POST("/auth/login", router.AuthHandler.AuthenticateWithCookie) // Authenticate and get a token
-	apiGroup.GET("/auth/me", router.AuthHandler.GetAuthUser)                // Check auth and return user
-	apiGroup.POST("/auth/logout", router.AuthHandler.Logout)                // Logout the user
+	apiGroup.POST("/auth/login", router.AuthHandler.AuthenticateWithCookie)              // Authenticate and get a token
+	apiGroup.GET("/auth/me", router.AuthHandler.GetAuthUser, authMiddleware.RequireAuth) // Check auth and return user
+	apiGroup.POST("/auth/logout", router.AuthHandler.Logout)                             // Logout the user
 
-	apiGroup.GET("/users/:id", router.UserHandler.GetUserByID)       // Get user by ID
-	apiGroup.POST("/users/create", router.UserHandler.CreateUser)    // Create a new user
-	apiGroup.PUT("/users/update/:id", router.UserHandler.UpdateUser) // Update user by ID
-	apiGroup.DELETE("/users/:id", router.UserHandler.DeleteUser)
+	apiGroup.GET("/users/:id", router.UserHandler.GetUserByID, authMiddleware.RequireAuth)       // Get user by ID
+	apiGroup.POST("/users/create", router.UserHandler.CreateUser)                                // Create a new user
+	apiGroup.PUT("/users/update/:id", router.UserHandler.UpdateUser, authMiddleware.RequireAuth) // Update user by ID
+	apiGroup.DELETE("/users/:id", router.UserHandler.DeleteUser, authMiddleware.RequireAuth)
 
 	// Rental endpoints
-	apiGroup.POST("/rental/add", router.RentalHandler.AddRental)
+	apiGroup.POST("/rental/add", router.RentalHandler.AddRental, authMiddleware.RequireAuth)
+	apiGroup.POST("/rental", router.RentalHandler.CreateRental, authMiddleware.RequireAuth)
+	apiGroup.POST("/rental/images", router.RentalHandler.UploadRentalImages, authMiddleware.RequireAuth)
+	apiGroup.POST("/rental/import", router.RentalHandler.ImportRentals, authMiddleware.RequireAuth)
+	apiGroup.GET("/rental/export", router.RentalHandler.ExportRentals, authMiddleware.RequireAuth)
+	apiGroup.GET("/rental/drafts", router.RentalHandler.GetDrafts, authMiddleware.RequireAuth)
+	apiGroup.POST("/rental/drafts", router.RentalHandler.SaveDraft, authMiddleware.RequireAuth)
+	apiGroup.PUT("/rental/drafts/:id", router.RentalHandler.UpdateDraft, authMiddleware.RequireAuth)
+	apiGroup.POST("/rental/:id/publish", router.RentalHandler.PublishRental, authMiddleware.RequireAuth)
+	apiGroup.GET("/rental/expiring", router.RentalHandler.GetExpiringRentals, authMiddleware.RequireAuth)
+	apiGroup.POST("/rental/:id/renew", router.RentalHandler.RenewRental, authMiddleware.RequireAuth)
 	apiGroup.GET("/rental/list", router.RentalHandler.GetAllRentals)
-	apiGroup.GET("/rental/:id", router.RentalHandler.GetRentalByID)
-	apiGroup.PUT("/rental/:id", router.RentalHandler.UpdateRental)
-	apiGroup.DELETE("/rental/:id", router.RentalHandler.DeleteRental)
-	apiGroup.GET("/rental/user/:id", router.RentalHandler.GetRentalsByUserID)
+	apiGroup.GET("/rental/near", router.RentalHandler.NearRentals)
+	apiGroup.GET("/rental/markers", router.RentalHandler.GetRentalMarkers)
+	apiGroup.POST("/rental/markers", router.RentalHandler.GetRentalMarkers)
+	apiGroup.GET("/rental/clusters", router.RentalHandler.GetRentalClusters)
+	apiGroup.GET("/rental/facets", router.RentalHandler.GetRentalFacets)
+	apiGroup.GET("/rental/:id", router.RentalHandler.GetRentalByID, authMiddleware.OptionalAuth)
+	apiGroup.PUT("/rental/:id", router.RentalHandler.UpdateRental, authMiddleware.RequireAuth)
+	apiGroup.PATCH("/rental/:id", router.RentalHandler.PatchRental, authMiddleware.RequireAuth)
+	apiGroup.DELETE("/rental/:id", router.RentalHandler.DeleteRental, authMiddleware.RequireAuth)
+	apiGroup.POST("/rental/:id/restore", router.RentalHandler.RestoreRental, authMiddleware.RequireAuth)
+	apiGroup.GET("/rental/:id/history", router.RentalHandler.GetRentalHistory, authMiddleware.RequireAuth)
+
+	// Rental availability calendar
+	apiGroup.GET("/rental/:id/availability", router.RentalHandler.GetRentalAvailability, authMiddleware.OptionalAuth)
+	apiGroup.POST("/rental/:id/availability", router.RentalHandler.AddAvailabilityBlock, authMiddleware.RequireAuth)
+	apiGroup.DELETE("/rental/:id/availability/:blockId", router.RentalHandler.RemoveAvailabilityBlock, authMiddleware.RequireAuth)
+	apiGroup.GET("/rental/:id/calendar.ics", router.RentalHandler.ExportRentalCalendar, authMiddleware.OptionalAuth)
+	apiGroup.POST("/rental/:id/calendar/import", router.RentalHandler.ImportRentalCalendar, authMiddleware.RequireAuth)
+	apiGroup.GET("/rental/user/:id", router.RentalHandler.GetRentalsByUserID, authMiddleware.OptionalAuth)
+
+	// Rental moderation, admins only
+	apiGroup.POST("/rental/:id/approve", router.RentalHandler.ApproveRental, authMiddleware.RequireAdmin)
+	apiGroup.POST("/rental/:id/decline", router.RentalHandler.DeclineRental, authMiddleware.RequireAdmin)
+	apiGroup.GET("/admin/rentals/pending", router.RentalHandler.GetPendingRentals, authMiddleware.RequireAdmin)
+	apiGroup.GET("/admin/rentals/duplicates", router.RentalHandler.GetSuspectedDuplicates, authMiddleware.RequireAdmin)
+	apiGroup.DELETE("/rental/:id/duplicates/:otherId", router.RentalHandler.DismissDuplicate, authMiddleware.RequireAdmin)
+
+	// Saved search endpoints, scoped to the authenticated user
+	savedSearches := apiGroup.Group("/saved-searches", authMiddleware.RequireAuth)
+	savedSearches.GET("", router.SavedSearchHandler.GetSavedSearches)
+	savedSearches.POST("", router.SavedSearchHandler.CreateSavedSearch)
+	savedSearches.GET("/matches", router.SavedSearchHandler.GetUnseenMatches)
+	savedSearches.POST("/:id/seen", router.SavedSearchHandler.MarkSeen)
+	savedSearches.DELETE("/:id", router.SavedSearchHandler.DeleteSavedSearch)
+
+	// Favorite endpoints, tenants bookmark rentals and owners count the favorites of their listings
+	favorites := apiGroup.Group("/favorites", authMiddleware.RequireAuth)
+	favorites.GET("", router.FavoriteHandler.GetFavorites)
+	favorites.GET("/counts", router.FavoriteHandler.GetFavoriteCounts)
+	favorites.PUT("/:rentalId", router.FavoriteHandler.AddFavorite)
+	favorites.DELETE("/:rentalId", router.FavoriteHandler.RemoveFavorite)
+
+	// Reservation endpoints, the authenticated user acts as the tenant or as the owner of the rental
+	reservations := apiGroup.Group("/reservations", authMiddleware.RequireAuth)
+	reservations.POST("", router.ReservationHandler.RequestReservation)
+	reservations.GET("", router.ReservationHandler.GetMyReservations)
+	reservations.GET("/incoming", router.ReservationHandler.GetIncomingReservations)
+	reservations.POST("/:id/accept", router.ReservationHandler.AcceptReservation)
+	reservations.POST("/:id/reject", router.ReservationHandler.RejectReservation)
+	reservations.POST("/:id/cancel", router.ReservationHandler.CancelReservation)
+
+	// Viewing endpoints, landlords publish slots on their rentals and tenants book a seat
+	apiGroup.GET("/rental/:id/viewings", router.ViewingHandler.GetRentalSlots)
+	apiGroup.POST("/rental/:id/viewings", router.ViewingHandler.CreateSlot, authMiddleware.RequireAuth)
+	viewings := apiGroup.Group("/viewings", authMiddleware.RequireAuth)
+	viewings.GET("", router.ViewingHandler.GetMyViewings)
+	viewings.GET("/calendar.ics", router.ViewingHandler.GetMyCalendar)
+	viewings.POST("/slots/:id/book", router.ViewingHandler.BookSlot)
+	viewings.DELETE("/slots/:id", router.ViewingHandler.CancelSlot)
+	viewings.POST("/:id/cancel", router.ViewingHandler.CancelBooking)
+	viewings.GET("/:id/invite.ics", router.ViewingHandler.GetBookingInvite)
+
+	// Exchange rates, prices are converted with ?currency= on the rental endpoints
+	apiGroup.GET("/currency/rates", router.CurrencyHandler.GetRates)
+	apiGroup.PUT("/admin/currency/rates", router.CurrencyHandler.SetRates, authMiddleware.RequireAdmin)
+	apiGroup.POST("/admin/currency/rates/import", router.CurrencyHandler.ImportRates, authMiddleware.RequireAdmin)
 
 	// Places endpoints
 	apiGroup.GET("/placeDetails", router.PlacesHandler.GetPlaceDetails)
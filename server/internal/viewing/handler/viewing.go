@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"server/config"
+	"server/internal/ical"
+	"server/internal/viewing/service"
+	"server/internal/viewing/types"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+type ViewingHandler struct {
+	service service.ViewingService
+}
+
+func NewViewingHandler(service service.ViewingService) *ViewingHandler {
+	return &ViewingHandler{service: service}
+}
+
+// CreateSlot handles the POST request of a landlord publishing a viewing slot on a rental.
+// Times are RFC 3339: {"start": "2024-07-01T10:00:00+01:00", "end": "2024-07-01T10:30:00+01:00", "capacity": 4}.
+func (h *ViewingHandler) CreateSlot(c echo.Context) error {
+	var body struct {
+		Start    time.Time `json:"start"`
+		End      time.Time `json:"end"`
+		Capacity int       `json:"capacity"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input data, times are expected as RFC 3339"})
+	}
+
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	slot, err := h.service.CreateSlot(c.Request().Context(), claims.UserID, c.Param("id"), types.Slot{
+		Start:    body.Start,
+		End:      body.End,
+		Capacity: body.Capacity,
+	})
+	if err != nil {
+		return viewingError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, slot)
+}
+
+// GetRentalSlots handles the GET request to list the upcoming viewing slots of a rental
+func (h *ViewingHandler) GetRentalSlots(c echo.Context) error {
+	slots, err := h.service.GetRentalSlots(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return viewingError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, slots)
+}
+
+// CancelSlot handles the DELETE request of a landlord withdrawing a slot, which cancels its viewings
+func (h *ViewingHandler) CancelSlot(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	if err := h.service.CancelSlot(c.Request().Context(), claims.UserID, c.Param("id")); err != nil {
+		return viewingError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Viewing slot cancelled successfully"})
+}
+
+// BookSlot handles the POST request of a tenant taking a seat in a viewing slot
+func (h *ViewingHandler) BookSlot(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	booking, err := h.service.BookSlot(c.Request().Context(), claims.UserID, c.Param("id"))
+	if err != nil {
+		return viewingError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, booking)
+}
+
+// GetMyViewings handles the GET request to list the viewings of the authenticated user, as a tenant and as a landlord
+func (h *ViewingHandler) GetMyViewings(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	viewings, err := h.service.GetUserViewings(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve viewings"})
+	}
+
+	return c.JSON(http.StatusOK, viewings)
+}
+
+// CancelBooking handles the POST request of the tenant or the landlord cancelling a viewing
+func (h *ViewingHandler) CancelBooking(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	if err := h.service.CancelBooking(c.Request().Context(), claims.UserID, c.Param("id")); err != nil {
+		return viewingError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Viewing cancelled successfully"})
+}
+
+// GetBookingInvite handles the GET request for the .ics invitation of a viewing
+func (h *ViewingHandler) GetBookingInvite(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	calendar, err := h.service.GetBookingInvite(c.Request().Context(), claims.UserID, c.Param("id"))
+	if err != nil {
+		return viewingError(c, err)
+	}
+
+	return writeCalendar(c, calendar, "attachment", "viewing-"+c.Param("id")+".ics")
+}
+
+// GetMyCalendar handles the GET request for the iCalendar feed of the viewings of the authenticated user
+func (h *ViewingHandler) GetMyCalendar(c echo.Context) error {
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*config.JWTClaims)
+
+	calendar, err := h.service.GetUserCalendar(c.Request().Context(), claims.UserID)
+	if err != nil {
+		return viewingError(c, err)
+	}
+
+	return writeCalendar(c, calendar, "inline", "viewings.ics")
+}
+
+func writeCalendar(c echo.Context, calendar *ical.Calendar, disposition string, filename string) error {
+	contentType := "text/calendar; charset=utf-8"
+	if calendar.Method != "" {
+		contentType += "; method=" + calendar.Method
+	}
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, disposition+`; filename="`+filename+`"`)
+	c.Response().WriteHeader(http.StatusOK)
+	return calendar.Encode(c.Response())
+}
+
+func viewingError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrSlotNotFound), errors.Is(err, service.ErrBookingNotFound), errors.Is(err, service.ErrRentalNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrNotAllowed):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrSlotFull), errors.Is(err, service.ErrAlreadyBooked), errors.Is(err, service.ErrStatusConflict):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"server/internal/viewing/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrSlotFull is returned when a slot has no seat left, or is no longer open
+	ErrSlotFull = errors.New("the viewing slot is full")
+	// ErrAlreadyBooked is returned when a tenant already holds a seat in the slot
+	ErrAlreadyBooked = errors.New("you already booked this viewing slot")
+	// ErrStatusConflict is returned when a slot or a booking was cancelled meanwhile
+	ErrStatusConflict = errors.New("already cancelled")
+)
+
+type ViewingRepository interface {
+	CreateSlot(ctx context.Context, slot *types.Slot) error
+	GetSlotByID(ctx context.Context, id primitive.ObjectID) (*types.Slot, error)
+	GetUpcomingSlots(ctx context.Context, rentalID primitive.ObjectID, from time.Time) ([]types.Slot, error)
+	ClaimSeat(ctx context.Context, slotID primitive.ObjectID) error
+	ReleaseSeat(ctx context.Context, slotID primitive.ObjectID) error
+	CancelSlot(ctx context.Context, slotID primitive.ObjectID) error
+	CreateBooking(ctx context.Context, booking *types.Booking) error
+	GetBookingByID(ctx context.Context, id primitive.ObjectID) (*types.Booking, error)
+	GetBookingsByTenantID(ctx context.Context, tenantID primitive.ObjectID) ([]types.Booking, error)
+	GetBookingsByOwnerID(ctx context.Context, ownerID primitive.ObjectID) ([]types.Booking, error)
+	CancelBooking(ctx context.Context, id primitive.ObjectID) error
+	CancelBookingsBySlotID(ctx context.Context, slotID primitive.ObjectID) error
+}
+
+type viewingRepository struct {
+	slots    *mongo.Collection
+	bookings *mongo.Collection
+}
+
+func NewViewingRepository(db *mongo.Database) ViewingRepository {
+	return &viewingRepository{
+		slots:    db.Collection("viewing_slots"),
+		bookings: db.Collection("viewing_bookings"),
+	}
+}
+
+// CreateSlot stores a new viewing slot
+func (r *viewingRepository) CreateSlot(ctx context.Context, slot *types.Slot) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	slot.ID = primitive.NewObjectID()
+	slot.CreatedAt = time.Now()
+
+	if _, err := r.slots.InsertOne(ctx, slot); err != nil {
+		log.Printf("Error inserting viewing slot: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetSlotByID retrieves a viewing slot by its ID
+func (r *viewingRepository) GetSlotByID(ctx context.Context, id primitive.ObjectID) (*types.Slot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var slot types.Slot
+	if err := r.slots.FindOne(ctx, bson.M{"_id": id}).Decode(&slot); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Error finding viewing slot: %v", err)
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// GetUpcomingSlots retrieves the open slots of a rental starting after from, soonest first
+func (r *viewingRepository) GetUpcomingSlots(ctx context.Context, rentalID primitive.ObjectID, from time.Time) ([]types.Slot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"rentalId": rentalID, "status": types.SlotOpen, "start": bson.M{"$gt": from}}
+	cursor, err := r.slots.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "start", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding viewing slots: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	slots := []types.Slot{}
+	if err = cursor.All(ctx, &slots); err != nil {
+		log.Printf("Error decoding viewing slots: %v", err)
+		return nil, err
+	}
+	return slots, nil
+}
+
+// ClaimSeat takes a seat in an open slot that has not started yet.
+// The capacity check and the increment happen in a single update, so a slot is never overbooked.
+func (r *viewingRepository) ClaimSeat(ctx context.Context, slotID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":    slotID,
+		"status": types.SlotOpen,
+		"start":  bson.M{"$gt": time.Now()},
+		"$expr":  bson.M{"$lt": bson.A{"$booked", "$capacity"}},
+	}
+	result, err := r.slots.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"booked": 1}})
+	if err != nil {
+		log.Printf("Error claiming viewing seat: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrSlotFull
+	}
+	return nil
+}
+
+// ReleaseSeat gives back a seat of a slot
+func (r *viewingRepository) ReleaseSeat(ctx context.Context, slotID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": slotID, "booked": bson.M{"$gt": 0}}
+	if _, err := r.slots.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"booked": -1}}); err != nil {
+		log.Printf("Error releasing viewing seat: %v", err)
+		return err
+	}
+	return nil
+}
+
+// CancelSlot closes an open slot
+func (r *viewingRepository) CancelSlot(ctx context.Context, slotID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": slotID, "status": types.SlotOpen}
+	result, err := r.slots.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"status": types.SlotCancelled}})
+	if err != nil {
+		log.Printf("Error cancelling viewing slot: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+// CreateBooking stores a new booking. A tenant holds at most one confirmed booking per slot,
+// which a unique index enforces.
+func (r *viewingRepository) CreateBooking(ctx context.Context, booking *types.Booking) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	booking.ID = primitive.NewObjectID()
+	booking.CreatedAt = time.Now()
+	booking.UpdatedAt = booking.CreatedAt
+
+	if _, err := r.bookings.InsertOne(ctx, booking); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAlreadyBooked
+		}
+		log.Printf("Error inserting viewing booking: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetBookingByID retrieves a booking by its ID
+func (r *viewingRepository) GetBookingByID(ctx context.Context, id primitive.ObjectID) (*types.Booking, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var booking types.Booking
+	if err := r.bookings.FindOne(ctx, bson.M{"_id": id}).Decode(&booking); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Error finding viewing booking: %v", err)
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// GetBookingsByTenantID retrieves the bookings of a tenant, soonest first
+func (r *viewingRepository) GetBookingsByTenantID(ctx context.Context, tenantID primitive.ObjectID) ([]types.Booking, error) {
+	return r.findBookings(ctx, bson.M{"tenantId": tenantID})
+}
+
+// GetBookingsByOwnerID retrieves the bookings on the slots of an owner, soonest first
+func (r *viewingRepository) GetBookingsByOwnerID(ctx context.Context, ownerID primitive.ObjectID) ([]types.Booking, error) {
+	return r.findBookings(ctx, bson.M{"ownerId": ownerID})
+}
+
+// CancelBooking cancels a confirmed booking and bumps the revision of its invitation
+func (r *viewingRepository) CancelBooking(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": types.BookingConfirmed}
+	result, err := r.bookings.UpdateOne(ctx, filter, cancelBooking())
+	if err != nil {
+		log.Printf("Error cancelling viewing booking: %v", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+// CancelBookingsBySlotID cancels every confirmed booking of a slot
+func (r *viewingRepository) CancelBookingsBySlotID(ctx context.Context, slotID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"slotId": slotID, "status": types.BookingConfirmed}
+	if _, err := r.bookings.UpdateMany(ctx, filter, cancelBooking()); err != nil {
+		log.Printf("Error cancelling viewing bookings: %v", err)
+		return err
+	}
+	return nil
+}
+
+func cancelBooking() bson.M {
+	return bson.M{
+		"$set": bson.M{"status": types.BookingCancelled, "updatedAt": time.Now()},
+		"$inc": bson.M{"sequence": 1},
+	}
+}
+
+func (r *viewingRepository) findBookings(ctx context.Context, filter bson.M) ([]types.Booking, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.bookings.Find(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Error finding viewing bookings: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []types.Booking{}
+	if err = cursor.All(ctx, &bookings); err != nil {
+		log.Printf("Error decoding viewing bookings: %v", err)
+		return nil, err
+	}
+	return bookings, nil
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"server/internal/ical"
+	rentalRepository "server/internal/rental/repository"
+	rentalTypes "server/internal/rental/types"
+	userService "server/internal/user/service"
+	"server/internal/viewing/repository"
+	"server/internal/viewing/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	prodID = "-//Rentals//Viewings//EN"
+	// maxSlotLength bounds the duration of a viewing slot
+	maxSlotLength = 8 * time.Hour
+)
+
+var (
+	// ErrSlotNotFound is returned when no viewing slot has the given ID
+	ErrSlotNotFound = errors.New("no viewing slot found with the given ID")
+	// ErrBookingNotFound is returned when no viewing booking has the given ID
+	ErrBookingNotFound = errors.New("no viewing found with the given ID")
+	// ErrRentalNotFound is returned when the rental does not exist or is not published
+	ErrRentalNotFound = errors.New("no rental found with the given ID")
+	// ErrNotAllowed is returned when the user is neither the landlord nor the tenant of a viewing
+	ErrNotAllowed = errors.New("not allowed to act on this viewing")
+	// ErrSlotFull is returned when the slot has no seat left, has started or was cancelled
+	ErrSlotFull = repository.ErrSlotFull
+	// ErrAlreadyBooked is returned when the tenant already holds a seat in the slot
+	ErrAlreadyBooked = repository.ErrAlreadyBooked
+	// ErrStatusConflict is returned when the slot or the viewing is already cancelled
+	ErrStatusConflict = repository.ErrStatusConflict
+)
+
+type ViewingService interface {
+	CreateSlot(ctx context.Context, ownerID string, rentalID string, slot types.Slot) (*types.Slot, error)
+	GetRentalSlots(ctx context.Context, rentalID string) ([]types.Slot, error)
+	CancelSlot(ctx context.Context, ownerID string, slotID string) error
+	BookSlot(ctx context.Context, tenantID string, slotID string) (*types.Booking, error)
+	CancelBooking(ctx context.Context, userID string, id string) error
+	GetUserViewings(ctx context.Context, userID string) (*types.UserViewings, error)
+	GetBookingInvite(ctx context.Context, userID string, id string) (*ical.Calendar, error)
+	GetUserCalendar(ctx context.Context, userID string) (*ical.Calendar, error)
+}
+
+type viewingService struct {
+	repo        repository.ViewingRepository
+	rentalRepo  rentalRepository.RentalRepository
+	userService userService.UserService
+}
+
+func NewViewingService(repo repository.ViewingRepository, rentalRepo rentalRepository.RentalRepository, userService userService.UserService) ViewingService {
+	return &viewingService{repo: repo, rentalRepo: rentalRepo, userService: userService}
+}
+
+// CreateSlot publishes a viewing slot on a rental of the landlord
+func (s *viewingService) CreateSlot(ctx context.Context, ownerID string, rentalID string, slot types.Slot) (*types.Slot, error) {
+	rental, err := s.rentalRepo.GetRentalByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rental == nil {
+		return nil, ErrRentalNotFound
+	}
+	if rental.CreatedBy.Hex() != ownerID {
+		return nil, ErrNotAllowed
+	}
+
+	if !slot.Start.After(time.Now()) {
+		return nil, errors.New("the viewing must start in the future")
+	}
+	if !slot.End.After(slot.Start) {
+		return nil, errors.New("the viewing must end after it starts")
+	}
+	if slot.End.Sub(slot.Start) > maxSlotLength {
+		return nil, fmt.Errorf("a viewing cannot last more than %s", maxSlotLength)
+	}
+	if slot.Capacity < 1 || slot.Capacity > types.MaxSlotCapacity {
+		return nil, fmt.Errorf("the capacity must be between 1 and %d", types.MaxSlotCapacity)
+	}
+
+	slot.RentalID = rental.ID
+	slot.OwnerID = rental.CreatedBy
+	slot.Booked = 0
+	slot.Status = types.SlotOpen
+	if err := s.repo.CreateSlot(ctx, &slot); err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// GetRentalSlots retrieves the upcoming open slots of a rental
+func (s *viewingService) GetRentalSlots(ctx context.Context, rentalID string) ([]types.Slot, error) {
+	id, err := primitive.ObjectIDFromHex(rentalID)
+	if err != nil {
+		return nil, errors.New("invalid rental ID")
+	}
+	return s.repo.GetUpcomingSlots(ctx, id, time.Now())
+}
+
+// CancelSlot withdraws a slot along with the viewings booked in it
+func (s *viewingService) CancelSlot(ctx context.Context, ownerID string, slotID string) error {
+	slot, err := s.loadSlot(ctx, slotID)
+	if err != nil {
+		return err
+	}
+	if slot.OwnerID.Hex() != ownerID {
+		return ErrNotAllowed
+	}
+
+	if err := s.repo.CancelSlot(ctx, slot.ID); err != nil {
+		return err
+	}
+	return s.repo.CancelBookingsBySlotID(ctx, slot.ID)
+}
+
+// BookSlot takes a seat for the tenant in a slot of a published rental
+func (s *viewingService) BookSlot(ctx context.Context, tenantID string, slotID string) (*types.Booking, error) {
+	tenant, err := primitive.ObjectIDFromHex(tenantID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	slot, err := s.loadSlot(ctx, slotID)
+	if err != nil {
+		return nil, err
+	}
+	if slot.OwnerID == tenant {
+		return nil, errors.New("you cannot book a viewing of your own rental")
+	}
+
+	rental, err := s.rentalRepo.GetRentalByID(ctx, slot.RentalID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if rental == nil || rental.Status != rentalTypes.Agreed {
+		return nil, ErrRentalNotFound
+	}
+
+	if err := s.repo.ClaimSeat(ctx, slot.ID); err != nil {
+		return nil, err
+	}
+
+	booking := &types.Booking{
+		SlotID:   slot.ID,
+		RentalID: slot.RentalID,
+		OwnerID:  slot.OwnerID,
+		TenantID: tenant,
+		Start:    slot.Start,
+		End:      slot.End,
+		Status:   types.BookingConfirmed,
+	}
+	if err := s.repo.CreateBooking(ctx, booking); err != nil {
+		// The seat was taken for nothing, give it back
+		if releaseErr := s.repo.ReleaseSeat(ctx, slot.ID); releaseErr != nil {
+			log.Printf("Failed to release a seat of viewing slot %s: %v", slot.ID.Hex(), releaseErr)
+		}
+		return nil, err
+	}
+	return booking, nil
+}
+
+// CancelBooking cancels a viewing on behalf of its tenant or of the landlord, freeing the seat
+func (s *viewingService) CancelBooking(ctx context.Context, userID string, id string) error {
+	booking, err := s.loadBooking(ctx, id)
+	if err != nil {
+		return err
+	}
+	if booking.TenantID.Hex() != userID && booking.OwnerID.Hex() != userID {
+		return ErrNotAllowed
+	}
+
+	if err := s.repo.CancelBooking(ctx, booking.ID); err != nil {
+		return err
+	}
+	if err := s.repo.ReleaseSeat(ctx, booking.SlotID); err != nil {
+		log.Printf("Failed to release a seat of viewing slot %s: %v", booking.SlotID.Hex(), err)
+	}
+	return nil
+}
+
+// GetUserViewings retrieves the viewings of a user, as a tenant and as a landlord
+func (s *viewingService) GetUserViewings(ctx context.Context, userID string) (*types.UserViewings, error) {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	asTenant, err := s.repo.GetBookingsByTenantID(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	asOwner, err := s.repo.GetBookingsByOwnerID(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &types.UserViewings{AsTenant: asTenant, AsOwner: asOwner}, nil
+}
+
+// GetBookingInvite builds the invitation of a viewing, to be attached to an email or imported by either party.
+// A cancelled viewing gives a CANCEL message, which removes the event from the calendar it was imported in.
+func (s *viewingService) GetBookingInvite(ctx context.Context, userID string, id string) (*ical.Calendar, error) {
+	booking, err := s.loadBooking(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if booking.TenantID.Hex() != userID && booking.OwnerID.Hex() != userID {
+		return nil, ErrNotAllowed
+	}
+
+	method := "REQUEST"
+	if booking.Status == types.BookingCancelled {
+		method = "CANCEL"
+	}
+	return &ical.Calendar{
+		ProdID: prodID,
+		Method: method,
+		Events: []ical.Event{s.bookingEvent(ctx, *booking)},
+	}, nil
+}
+
+// GetUserCalendar builds the feed of the viewings of a user, as a tenant and as a landlord.
+// Cancelled viewings stay in the feed with a CANCELLED status so that subscribed calendars drop them.
+func (s *viewingService) GetUserCalendar(ctx context.Context, userID string) (*ical.Calendar, error) {
+	viewings, err := s.GetUserViewings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ical.Calendar{ProdID: prodID, Name: "Viewings"}
+	for _, booking := range append(viewings.AsTenant, viewings.AsOwner...) {
+		calendar.Events = append(calendar.Events, s.bookingEvent(ctx, booking))
+	}
+	return calendar, nil
+}
+
+// bookingEvent turns a booking into an event with the landlord as organizer and the tenant as attendee.
+// Missing rental or user details leave the matching fields empty rather than failing the export.
+func (s *viewingService) bookingEvent(ctx context.Context, booking types.Booking) ical.Event {
+	event := ical.Event{
+		UID:      booking.ID.Hex() + "@viewings",
+		Summary:  "Viewing",
+		Start:    booking.Start,
+		End:      booking.End,
+		Status:   "CONFIRMED",
+		Sequence: booking.Sequence,
+		Stamp:    booking.UpdatedAt,
+	}
+	if booking.Status == types.BookingCancelled {
+		event.Status = "CANCELLED"
+	}
+
+	rental, err := s.rentalRepo.GetRentalByID(ctx, booking.RentalID.Hex())
+	if err != nil {
+		log.Printf("Failed to fetch rental %s for viewing %s: %v", booking.RentalID.Hex(), booking.ID.Hex(), err)
+	}
+	if rental != nil {
+		event.Summary = "Viewing: " + rental.Name
+		event.Location = rental.Address.FullAddress
+	}
+
+	if owner := s.person(ctx, booking.OwnerID); owner != nil {
+		event.Organizer = owner
+		if owner.Name != "" {
+			event.Description = "Hosted by " + owner.Name
+		}
+	}
+	if tenant := s.person(ctx, booking.TenantID); tenant != nil {
+		event.Attendees = []ical.Person{*tenant}
+	}
+	return event
+}
+
+// person looks up the name and email of a user, or returns nil when the user is unknown
+func (s *viewingService) person(ctx context.Context, id primitive.ObjectID) *ical.Person {
+	user, err := s.userService.GetUserByID(ctx, id.Hex())
+	if err != nil || user == nil || user.Email == "" {
+		return nil
+	}
+	return &ical.Person{
+		Name:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Email: user.Email,
+	}
+}
+
+func (s *viewingService) loadSlot(ctx context.Context, id string) (*types.Slot, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid slot ID")
+	}
+	slot, err := s.repo.GetSlotByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if slot == nil {
+		return nil, ErrSlotNotFound
+	}
+	return slot, nil
+}
+
+func (s *viewingService) loadBooking(ctx context.Context, id string) (*types.Booking, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid viewing ID")
+	}
+	booking, err := s.repo.GetBookingByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if booking == nil {
+		return nil, ErrBookingNotFound
+	}
+	return booking, nil
+}
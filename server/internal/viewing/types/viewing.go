@@ -0,0 +1,59 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SlotStatus string
+
+const (
+	SlotOpen      SlotStatus = "open"
+	SlotCancelled SlotStatus = "cancelled"
+)
+
+type BookingStatus string
+
+const (
+	BookingConfirmed BookingStatus = "confirmed"
+	BookingCancelled BookingStatus = "cancelled"
+)
+
+// MaxSlotCapacity bounds the number of visitors of a single slot
+const MaxSlotCapacity = 50
+
+// Slot is a time window published by a landlord during which up to Capacity tenants can visit a rental
+type Slot struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RentalID  primitive.ObjectID `json:"rentalId" bson:"rentalId"`
+	OwnerID   primitive.ObjectID `json:"ownerId" bson:"ownerId"` // CreatedBy of the rental
+	Start     time.Time          `json:"start" bson:"start"`
+	End       time.Time          `json:"end" bson:"end"`
+	Capacity  int                `json:"capacity" bson:"capacity"`
+	Booked    int                `json:"booked" bson:"booked"` // Confirmed bookings, never above Capacity
+	Status    SlotStatus         `json:"status" bson:"status"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Booking is the seat of a tenant in a viewing slot.
+// The slot times and parties are copied so that bookings can be listed and exported on their own.
+type Booking struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SlotID    primitive.ObjectID `json:"slotId" bson:"slotId"`
+	RentalID  primitive.ObjectID `json:"rentalId" bson:"rentalId"`
+	OwnerID   primitive.ObjectID `json:"ownerId" bson:"ownerId"`
+	TenantID  primitive.ObjectID `json:"tenantId" bson:"tenantId"`
+	Start     time.Time          `json:"start" bson:"start"`
+	End       time.Time          `json:"end" bson:"end"`
+	Status    BookingStatus      `json:"status" bson:"status"`
+	Sequence  int                `json:"-" bson:"sequence"` // Revision of the calendar invitation, bumped on cancellation
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// UserViewings lists the bookings of a user on both sides
+type UserViewings struct {
+	AsTenant []Booking `json:"asTenant"`
+	AsOwner  []Booking `json:"asOwner"`
+}